@@ -0,0 +1,111 @@
+package dr
+
+import (
+	"sort"
+	"unicode"
+)
+
+// alphabet partitions the runes into classes, such that every rune in a
+// class has the same derivative for a given set of regexes. It is stored
+// as the sorted runes at which a new class begins; class 0 starts at 0.
+type alphabet []rune
+
+// newAlphabet creates the coarsest alphabet that distinguishes every
+// character mentioned in the given regexes.
+func newAlphabet(rs ...Regex) alphabet {
+	bounds := make(map[rune]bool)
+	for _, r := range rs {
+		addBounds(bounds, r)
+	}
+	delete(bounds, 0)
+
+	a := make(alphabet, 0, len(bounds))
+	for c := range bounds {
+		a = append(a, c)
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	return a
+}
+
+func addBounds(bounds map[rune]bool, r Regex) {
+	switch r := r.(type) {
+	case *char:
+		bounds[r.r] = true
+		bounds[r.r+1] = true
+	case *class:
+		for _, rr := range r.ranges() {
+			bounds[rr[0]] = true
+			bounds[rr[1]+1] = true
+		}
+	case *union:
+		addBounds(bounds, r.l)
+		addBounds(bounds, r.r)
+	case *intersection:
+		addBounds(bounds, r.l)
+		addBounds(bounds, r.r)
+	case *concat:
+		addBounds(bounds, r.l)
+		addBounds(bounds, r.r)
+	case *comp:
+		addBounds(bounds, r.r)
+	case *kleene:
+		addBounds(bounds, r.r)
+	case *anchor:
+		if r.kind == 'b' || r.kind == 'B' {
+			for _, rr := range tableRanges(perlTables['w']) {
+				bounds[rr[0]] = true
+				bounds[rr[1]+1] = true
+			}
+		}
+	case *lookahead:
+		addBounds(bounds, r.r)
+	case *located:
+		addBounds(bounds, r.r)
+	}
+}
+
+// size returns the number of classes.
+func (a alphabet) size() int {
+	return len(a) + 1
+}
+
+// class returns the class containing c.
+func (a alphabet) class(c rune) int {
+	return sort.Search(len(a), func(i int) bool { return a[i] > c })
+}
+
+// rep returns the smallest rune in class i.
+func (a alphabet) rep(i int) rune {
+	if i == 0 {
+		return 0
+	}
+	return a[i-1]
+}
+
+// bounds returns the smallest and largest runes in class i.
+func (a alphabet) bounds(i int) (lo, hi rune) {
+	lo, hi = a.rep(i), unicode.MaxRune
+	if i < len(a) {
+		hi = a[i] - 1
+	}
+	return lo, hi
+}
+
+// pick returns a readable rune in class i, for showing examples: a letter
+// or digit if the class has one, otherwise the first graphic rune near
+// the start of the class, falling back to rep.
+func (a alphabet) pick(i int) rune {
+	for _, c := range "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ" {
+		if a.class(c) == i {
+			return c
+		}
+	}
+
+	lo, hi := a.bounds(i)
+	for c := lo; c <= hi && c < lo+0x10000; c++ {
+		if unicode.IsGraphic(c) && !unicode.IsSpace(c) {
+			return c
+		}
+	}
+	return lo
+}
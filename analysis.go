@@ -0,0 +1,20 @@
+package dr
+
+// Subset returns true if every string matched by a is also matched by b.
+// Otherwise, it returns false and a shortest string matched by a but not
+// b, as a counterexample.
+func Subset(a, b Regex) (string, bool) {
+	w, found := Witness(NewIntersection(a, NewComp(b)))
+	return w, !found
+}
+
+// Equivalent returns true if a and b match the same strings. Otherwise,
+// it returns false and a shortest string matched by only one of them, as
+// a counterexample.
+func Equivalent(a, b Regex) (string, bool) {
+	w, found := Witness(NewUnion(
+		NewIntersection(a, NewComp(b)),
+		NewIntersection(b, NewComp(a)),
+	))
+	return w, !found
+}
@@ -0,0 +1,71 @@
+package dr
+
+import "testing"
+
+func TestSubset(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+		ok   bool
+	}{
+		{"ab", "a*b*", "", true},
+		{"a*b*", "ab", "", false},
+		{"(ab)*", "(a+b)*", "", true},
+		{`\d\d*`, `\w\w*`, "", true},
+		{`\w`, `\d`, "A", false},
+		{"^a", "a", "", true},
+	}
+
+	for _, test := range tests {
+		got, ok := Subset(MustParse(test.a), MustParse(test.b))
+		if got != test.want || ok != test.ok {
+			t.Errorf("Subset(%q, %q) = %q, %v, want %q, %v", test.a, test.b, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+		ok   bool
+	}{
+		{"(a*b*)*", "(a+b)*", "", true},
+		{"!(!a+!b)", "a&b", "", true},
+		{"a*", "aa*", "", false},
+		{"ab+ac", "a(b+c)", "", true},
+		{"(ab)*a", "a(ba)*", "", true},
+		{"a.", "ab", "a0", false},
+		{`\bx`, "x", "", true},
+	}
+
+	for _, test := range tests {
+		got, ok := Equivalent(MustParse(test.a), MustParse(test.b))
+		if got != test.want || ok != test.ok {
+			t.Errorf("Equivalent(%q, %q) = %q, %v, want %q, %v", test.a, test.b, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		pattern string
+		n       int
+		want    string
+	}{
+		{"a*", 3, "1"},
+		{"(a+b)*", 3, "8"},
+		{"[a-c][0-9]", 2, "30"},
+		{"[a-c][0-9]", 1, "0"},
+		{".", 1, "1112064"},
+		{"!a", 1, "1112063"},
+		{"a&b", 1, "0"},
+	}
+
+	for _, test := range tests {
+		d := MustCompile(MustParse(test.pattern), 0)
+		if got := d.Count(test.n).String(); got != test.want {
+			t.Errorf("Count(%q, %v) = %v, want %v", test.pattern, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package dr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An anchor matches the empty string at certain positions: the start or
+// end of the input, or a word boundary. Whether it matches depends on the
+// characters either side of the position, so anchors are handled following
+// Moseley et al., "Derivative Based Nonbacktracking Real-World Regex
+// Matching with Backtracking Semantics": derivatives and nullability are
+// taken at a location, given by the kind of character on each side.
+type anchor struct {
+	kind byte
+}
+
+// NewStart creates a regex that accepts the empty string at the start of
+// the input, like ^.
+//
+// Regexes with anchors must be matched by the functions and types in this
+// package, which track the location in the input. Taking derivatives
+// directly treats anchors as never matching.
+func NewStart() Regex {
+	return &anchor{'^'}
+}
+
+// NewEnd creates a regex that accepts the empty string at the end of the
+// input, like $. See NewStart for how anchors are matched.
+func NewEnd() Regex {
+	return &anchor{'$'}
+}
+
+// NewWordBoundary creates a regex that accepts the empty string between a
+// word character (\w) and a non-word character or the start or end of the
+// input, like \b. If negate is true, it accepts the empty string anywhere
+// else, like \B. See NewStart for how anchors are matched.
+func NewWordBoundary(negate bool) Regex {
+	if negate {
+		return &anchor{'B'}
+	}
+	return &anchor{'b'}
+}
+
+func (a *anchor) String() string {
+	if a.kind == 'b' || a.kind == 'B' {
+		return `\` + string(a.kind)
+	}
+	return string(a.kind)
+}
+
+// Derivative returns Empty, since anchors don't consume input.
+func (*anchor) Derivative(rune) Regex {
+	return NewEmpty()
+}
+
+// Accepting returns false, since the location is unknown.
+func (*anchor) Accepting() bool {
+	return false
+}
+
+// side is the kind of character on one side of a location.
+type side byte
+
+const (
+	sideEdge  side = iota // the start or end of the input
+	sideWord              // a word character
+	sideOther             // any other character
+)
+
+func sideOf(c rune) side {
+	if '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || c == '_' || 'a' <= c && c <= 'z' {
+		return sideWord
+	}
+	return sideOther
+}
+
+// holds returns true if the anchor matches between prev and next.
+func (a *anchor) holds(prev, next side) bool {
+	switch a.kind {
+	case '^':
+		return prev == sideEdge
+	case '$':
+		return next == sideEdge
+	case 'b':
+		return (prev == sideWord) != (next == sideWord)
+	default:
+		return (prev == sideWord) == (next == sideWord)
+	}
+}
+
+// nullableAtEnd returns true if r accepts the empty string at the end of
+// the input, following a character of kind prev. Nothing follows, so a
+// lookahead holds exactly when its regex accepts the empty string there.
+func nullableAtEnd(r Regex, prev side) bool {
+	switch r := r.(type) {
+	case *anchor:
+		return r.holds(prev, sideEdge)
+	case *lookahead:
+		return nullableAtEnd(r.r, prev) != r.neg
+	case *union:
+		return nullableAtEnd(r.l, prev) || nullableAtEnd(r.r, prev)
+	case *intersection:
+		return nullableAtEnd(r.l, prev) && nullableAtEnd(r.r, prev)
+	case *concat:
+		return nullableAtEnd(r.l, prev) && nullableAtEnd(r.r, prev)
+	case *comp:
+		return !nullableAtEnd(r.r, prev)
+	case *kleene:
+		return true
+	default:
+		return r.Accepting()
+	}
+}
+
+// input is text which is matched one character at a time, so that
+// lookaheads can read past the point where a match ends.
+type input interface {
+	// at returns the character at position i and the position after it,
+	// or false at the end of the input.
+	at(i int) (c rune, next int, ok bool)
+}
+
+// nullableIn returns true if r accepts the empty string at position i of
+// in, following a character of kind prev. Lookaheads are decided by
+// reading on from i, one character at a time, as derivatives would.
+func nullableIn(r Regex, prev side, in input, i int) bool {
+	for {
+		c, next, ok := in.at(i)
+		if !ok {
+			return nullableAtEnd(r, prev)
+		}
+
+		switch r = simplify(conditionAt(r, prev, c)); r.(type) {
+		case *epsilon:
+			return true
+		case *empty:
+			return false
+		}
+		prev, i = sideOf(c), next
+	}
+}
+
+// conditionAt returns a regex which accepts the empty string after c
+// exactly when r accepts the empty string before c, following a character
+// of kind prev. Anchors are decided by c, and a lookahead which c doesn't
+// decide becomes a lookahead for the rest of its regex. The result is ε
+// or ∅ whenever c decides it.
+func conditionAt(r Regex, prev side, c rune) Regex {
+	switch r := r.(type) {
+	case *anchor:
+		if r.holds(prev, sideOf(c)) {
+			return NewEpsilon()
+		}
+		return NewEmpty()
+	case *lookahead:
+		cond := anyCondition(conditionAt(r.r, prev, c), lookaheadFor(simplify(derivativeAt(r.r, prev, c))))
+		if r.neg {
+			return notCondition(cond)
+		}
+		return cond
+	case *union:
+		return anyCondition(conditionAt(r.l, prev, c), conditionAt(r.r, prev, c))
+	case *intersection:
+		return allConditions(conditionAt(r.l, prev, c), conditionAt(r.r, prev, c))
+	case *concat:
+		return allConditions(conditionAt(r.l, prev, c), conditionAt(r.r, prev, c))
+	case *comp:
+		return notCondition(conditionAt(r.r, prev, c))
+	case *kleene:
+		return NewEpsilon()
+	default:
+		if r.Accepting() {
+			return NewEpsilon()
+		}
+		return NewEmpty()
+	}
+}
+
+// lookaheadFor returns the condition that some prefix of what follows is
+// matched by r.
+func lookaheadFor(r Regex) Regex {
+	if r.Accepting() && !hasAssertions(r) {
+		return NewEpsilon()
+	}
+	return NewLookahead(r, false)
+}
+
+func anyCondition(l, r Regex) Regex {
+	switch {
+	case isEmpty(l), isEpsilon(r):
+		return r
+	case isEmpty(r), isEpsilon(l):
+		return l
+	}
+	return NewUnion(l, r)
+}
+
+// allConditions returns the condition that both l and r hold. Conditions
+// only accept the empty string, so their concatenation does.
+func allConditions(l, r Regex) Regex {
+	switch {
+	case isEmpty(l), isEpsilon(r):
+		return l
+	case isEmpty(r), isEpsilon(l):
+		return r
+	}
+	return NewConcat(l, r)
+}
+
+func notCondition(r Regex) Regex {
+	switch r.(type) {
+	case *empty:
+		return NewEpsilon()
+	case *epsilon:
+		return NewEmpty()
+	}
+	return NewLookahead(r, true)
+}
+
+// isCondition returns true if r is made of lookaheads, so it only accepts
+// the empty string, as the regexes returned by conditionAt do.
+func isCondition(r Regex) bool {
+	switch r := r.(type) {
+	case *lookahead:
+		return true
+	case *union:
+		return isCondition(r.l) && isCondition(r.r)
+	case *concat:
+		return isCondition(r.l) && isCondition(r.r)
+	default:
+		return false
+	}
+}
+
+// mergeConditions returns the concatenation of the condition l and rest,
+// with the conditions at the front of rest moved in with l, sorted and
+// without duplicates, so they don't pile up as derivatives carry them.
+func mergeConditions(l, rest Regex) Regex {
+	var s termSet
+	addConditions(&s, l)
+	for {
+		c, ok := rest.(*concat)
+		if !ok || !isCondition(c.l) {
+			break
+		}
+		addConditions(&s, c.l)
+		rest = c.r
+	}
+	if isCondition(rest) {
+		addConditions(&s, rest)
+		rest = NewEpsilon()
+	}
+	sort.Sort(&s)
+
+	n := len(s.terms)
+	if isEpsilon(rest) {
+		rest = s.terms[n-1]
+		n--
+	}
+	for i := n - 1; i >= 0; i-- {
+		rest = NewConcat(s.terms[i], rest)
+	}
+	return rest
+}
+
+func addConditions(s *termSet, r Regex) {
+	if c, ok := r.(*concat); ok {
+		addConditions(s, c.l)
+		addConditions(s, c.r)
+		return
+	}
+	s.add(r)
+}
+
+// derivativeAt returns the derivative of r with respect to c, which
+// follows a character of kind prev. Where r.l of a concatenation only
+// accepts the empty string under a lookahead, the derivative of r.r is
+// concatenated to the lookahead's condition after c, so it carries the
+// lookahead forward.
+func derivativeAt(r Regex, prev side, c rune) Regex {
+	switch r := r.(type) {
+	case *anchor, *lookahead:
+		return NewEmpty()
+	case *union:
+		return NewUnion(derivativeAt(r.l, prev, c), derivativeAt(r.r, prev, c))
+	case *intersection:
+		return NewIntersection(derivativeAt(r.l, prev, c), derivativeAt(r.r, prev, c))
+	case *concat:
+		d := NewConcat(derivativeAt(r.l, prev, c), r.r)
+		if cond := conditionAt(r.l, prev, c); !isEmpty(cond) {
+			d = NewUnion(d, NewConcat(cond, derivativeAt(r.r, prev, c)))
+		}
+		return d
+	case *comp:
+		return NewComp(derivativeAt(r.r, prev, c))
+	case *kleene:
+		return NewConcat(derivativeAt(r.r, prev, c), r)
+	default:
+		return r.Derivative(c)
+	}
+}
+
+// located is a regex with anchors or lookaheads at a known location in
+// the input, following a character of kind prev. Its derivatives track
+// the location as input is consumed, so anchors can be checked.
+type located struct {
+	r    Regex
+	prev side
+	memo *derivCache
+}
+
+// locate prepares r to be matched from the start of the input. Regexes
+// without anchors or lookaheads are returned as they are.
+func locate(r Regex) Regex {
+	return locateAt(r, sideEdge)
+}
+
+// locateAt is like locate, but for matching from a location following a
+// character of kind prev.
+func locateAt(r Regex, prev side) Regex {
+	if _, ok := r.(*located); ok || !hasAssertions(r) {
+		return r
+	}
+	return &located{r: r, prev: prev}
+}
+
+// hasAssertions returns true if r contains anchors or lookaheads, whose
+// matching depends on where r is in the input.
+func hasAssertions(r Regex) bool {
+	found := false
+	Walk(r, func(r Regex) bool {
+		switch r.(type) {
+		case *anchor, *lookahead, *located:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (l *located) String() string {
+	return l.r.String()
+}
+
+// Derivative returns the derivative of the regex at its location, at the
+// location after c. Once no anchors or lookaheads are left, the plain
+// derivative is returned.
+func (l *located) Derivative(c rune) Regex {
+	if d, ok := l.memo.lookup(c); ok {
+		return d
+	}
+
+	d := simplify(derivativeAt(l.r, l.prev, c))
+	if hasAssertions(d) {
+		d = &located{r: d, prev: sideOf(c)}
+	}
+	return l.memo.store(c, d)
+}
+
+// Accepting returns true if the regex accepts the empty string at the end
+// of the input.
+func (l *located) Accepting() bool {
+	return nullableAtEnd(l.r, l.prev)
+}
+
+// lookahead is a zero-width assertion that some prefix of what follows in
+// the input is matched by r, or that none is if neg is true.
+type lookahead struct {
+	r   Regex
+	neg bool
+}
+
+// NewLookahead creates a regex that accepts the empty string where some
+// prefix of the rest of the input is matched by r, like (?=r). If negate
+// is true, it accepts the empty string where no prefix is, like (?!r).
+//
+// Like anchors, lookaheads are only checked by the functions and types in
+// this package; see NewStart. Lookaheads see past the end of the regex,
+// so (?=b) at the end of a token checks the text that follows it.
+func NewLookahead(r Regex, negate bool) Regex {
+	if isEmpty(r) {
+		if negate {
+			return NewEpsilon()
+		}
+		return NewEmpty()
+	}
+	return &lookahead{r: r, neg: negate}
+}
+
+func (l *lookahead) String() string {
+	if l.neg {
+		return fmt.Sprintf("(?!%v)", l.r)
+	}
+	return fmt.Sprintf("(?=%v)", l.r)
+}
+
+// Derivative returns Empty, since lookaheads don't consume input.
+func (*lookahead) Derivative(rune) Regex {
+	return NewEmpty()
+}
+
+// Accepting returns false, since the location is unknown.
+func (*lookahead) Accepting() bool {
+	return false
+}
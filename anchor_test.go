@@ -0,0 +1,215 @@
+package dr
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+var anchorTests = []struct {
+	pattern string
+	matches []string
+	fails   []string
+}{
+	{"^abc$", []string{"abc"}, []string{"", "ab"}},
+	{"a^b", nil, []string{"ab", "a^b"}},
+	{".*^a.*", []string{"a", "ab"}, []string{"ba"}},
+	{".*b$.*", []string{"b", "ab"}, []string{"ba"}},
+	{`\^\$`, []string{"^$"}, []string{""}},
+	{`.*\bfoo\b.*`, []string{"foo", "a foo", "foo.", "(foo)"}, []string{"food", "afoo", "fo o"}},
+	{`.*\Boo\B.*`, []string{"foood"}, []string{"oo", "f oo", "foo"}},
+	{`\b`, nil, []string{""}},
+	{`\B`, []string{""}, nil},
+	{`(\ba)*`, []string{"", "a"}, []string{"aa"}},
+	{`(a\b+b)*`, []string{"", "a", "b", "bb"}, []string{"ab", "aa"}},
+	{"!(^a)", []string{"", "b", "aa"}, []string{"a"}},
+	{"(?=a).*", []string{"a", "abc"}, []string{"", "b"}},
+	{"(?!a).*", []string{"", "b", "ba"}, []string{"a", "ab"}},
+	{"(a(?=b))b", []string{"ab"}, []string{"a", "abb"}},
+	{"a(?=b.*$)(b+c)*", []string{"ab", "abc"}, []string{"a", "ac"}},
+	{"(?=.*\\d)(?=.*[a-z])\\w*", []string{"a1", "1a", "abc9"}, []string{"", "abc", "123"}},
+	{"((?=a)a)*", []string{"", "a", "aa"}, []string{"b", "ab"}},
+	{"(?=(?!b).)..", []string{"ab", "aa"}, []string{"ba", "a"}},
+	{"a(?=b)", nil, []string{"a", "ab"}},
+	{".*a(?=b).*", []string{"ab", "xabx"}, []string{"a", "ac", "ba"}},
+	{"((?=a*$)a)*b*", []string{"", "aa", "b"}, []string{"ab", "aab"}},
+	{`(?=.*\bfoo\b).*`, []string{"foo", "a foo b"}, []string{"food", ""}},
+}
+
+func TestAnchors(t *testing.T) {
+	for _, test := range anchorTests {
+		r, err := Parse(test.pattern)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %v", test.pattern, err)
+			continue
+		}
+
+		n := NewNFA(r)
+		d := MustCompile(r, 0)
+		s := NewSet([]Regex{r})
+		u := MustCompile(NewUTF8(r), 0)
+
+		check := func(input string, want bool) {
+			if got := Match(r, input); got != want {
+				t.Errorf("Match(%q, %q) = %v, want %v", test.pattern, input, got, want)
+			}
+			if got := n.Match(input); got != want {
+				t.Errorf("NFA for %q matching %q = %v, want %v", test.pattern, input, got, want)
+			}
+			if got := d.Match(input); got != want {
+				t.Errorf("DFA for %q matching %q = %v, want %v", test.pattern, input, got, want)
+			}
+			if got := len(s.Match(input)) == 1; got != want {
+				t.Errorf("Set of %q matching %q = %v, want %v", test.pattern, input, got, want)
+			}
+			if got := u.MatchBytes([]byte(input)); got != want {
+				t.Errorf("UTF-8 DFA for %q matching %q = %v, want %v", test.pattern, input, got, want)
+			}
+		}
+
+		for _, input := range test.matches {
+			check(input, true)
+		}
+		for _, input := range test.fails {
+			check(input, false)
+		}
+	}
+}
+
+func TestAnchorsCompose(t *testing.T) {
+	r := NewConcat(MustParse("a"), MustParse(`\b.*`))
+	if !Match(r, "a b") || Match(r, "ab") {
+		t.Errorf("%v should match \"a b\" but not \"ab\"", r)
+	}
+
+	r = NewConcat(MustParse(".*"), MustParse("^b"))
+	if !Match(r, "b") || Match(r, "ab") {
+		t.Errorf("%v should match \"b\" but not \"ab\"", r)
+	}
+}
+
+func TestLookaheadRoundTrip(t *testing.T) {
+	for _, pattern := range []string{"(?=.*b)a.*", "a(?!b)", "((?=a)a)*", "((?=a)b+c)d", "((?!a)b)*", `(\?=a)(?=\?)`} {
+		r := MustParse(pattern)
+		if got := r.String(); got != pattern {
+			t.Errorf("MustParse(%q).String() = %q", pattern, got)
+		}
+	}
+}
+
+// refEnds returns the byte offsets j for which r matches s[i:j], seeing
+// the whole of s, by following the definition of each node directly.
+func refEnds(r Regex, s string, i int) map[int]bool {
+	ends := make(map[int]bool)
+	switch r := r.(type) {
+	case *epsilon:
+		ends[i] = true
+	case *anchor:
+		prev, next := sideEdge, sideEdge
+		if i > 0 {
+			c, _ := utf8.DecodeLastRuneInString(s[:i])
+			prev = sideOf(c)
+		}
+		if i < len(s) {
+			c, _ := utf8.DecodeRuneInString(s[i:])
+			next = sideOf(c)
+		}
+		if r.holds(prev, next) {
+			ends[i] = true
+		}
+	case *lookahead:
+		if (len(refEnds(r.r, s, i)) != 0) != r.neg {
+			ends[i] = true
+		}
+	case *union:
+		for j := range refEnds(r.l, s, i) {
+			ends[j] = true
+		}
+		for j := range refEnds(r.r, s, i) {
+			ends[j] = true
+		}
+	case *intersection:
+		right := refEnds(r.r, s, i)
+		for j := range refEnds(r.l, s, i) {
+			if right[j] {
+				ends[j] = true
+			}
+		}
+	case *concat:
+		for k := range refEnds(r.l, s, i) {
+			for j := range refEnds(r.r, s, k) {
+				ends[j] = true
+			}
+		}
+	case *comp:
+		inner := refEnds(r.r, s, i)
+		for j := i; ; {
+			if !inner[j] {
+				ends[j] = true
+			}
+			if j == len(s) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(s[j:])
+			j += size
+		}
+	case *kleene:
+		ends[i] = true
+		for todo := []int{i}; len(todo) != 0; todo = todo[1:] {
+			for j := range refEnds(r.r, s, todo[0]) {
+				if j > todo[0] && !ends[j] {
+					ends[j] = true
+					todo = append(todo, j)
+				}
+			}
+		}
+	default:
+		if i < len(s) {
+			c, size := utf8.DecodeRuneInString(s[i:])
+			if r.Derivative(c).Accepting() {
+				ends[i+size] = true
+			}
+		}
+	}
+	return ends
+}
+
+func TestLookaheadReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	const chars = "ab ."
+
+	for i := 0; i < 2000; i++ {
+		r := randomRegex(rnd, 1+rnd.Intn(4))
+		d := MustCompile(r, 0)
+
+		for k := 0; k < 10; k++ {
+			b := make([]byte, rnd.Intn(6))
+			for j := range b {
+				b[j] = chars[rnd.Intn(len(chars))]
+			}
+			s := string(b)
+
+			want := refEnds(r, s, 0)[len(s)]
+			if got := Match(r, s); got != want {
+				t.Errorf("Match(%v, %q) = %v, want %v", r, s, got, want)
+			}
+			if got := d.Match(s); got != want {
+				t.Errorf("DFA for %v matching %q = %v, want %v", r, s, got, want)
+			}
+
+			var wantIndex []int
+			for start := 0; start <= len(s) && wantIndex == nil; start++ {
+				for end := len(s); end >= start; end-- {
+					if refEnds(r, s, start)[end] {
+						wantIndex = []int{start, end}
+						break
+					}
+				}
+			}
+			if got := FindIndex(r, s); !reflect.DeepEqual(got, wantIndex) {
+				t.Errorf("FindIndex(%v, %q) = %v, want %v", r, s, got, wantIndex)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package dr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PartialDerivatives returns the Antimirov partial derivatives of r with
+// respect to c. The union of the returned regexes accepts the same language
+// as r.Derivative(c), but where the derivative of a union is one large
+// term, the partial derivatives are kept as a set of smaller terms, each
+// of which is a suffix of r.
+//
+// Complements do not distribute over partial derivatives, so the
+// derivative of a complement is returned as a single term.
+func PartialDerivatives(r Regex, c rune) []Regex {
+	var s termSet
+	s.addPartials(r, c)
+	return s.terms
+}
+
+func (s *termSet) addPartials(r Regex, c rune) {
+	switch r := r.(type) {
+	case *empty, *epsilon:
+	case *char:
+		if r.r == c {
+			s.add(NewEpsilon())
+		}
+	case *any:
+		s.add(NewEpsilon())
+	case *union:
+		s.addPartials(r.l, c)
+		s.addPartials(r.r, c)
+	case *concat:
+		for _, p := range PartialDerivatives(r.l, c) {
+			s.add(concatRight(p, r.r))
+		}
+		if r.l.Accepting() {
+			s.addPartials(r.r, c)
+		}
+	case *kleene:
+		for _, p := range PartialDerivatives(r.r, c) {
+			s.add(concatRight(p, r))
+		}
+	default:
+		s.add(r.Derivative(c))
+	}
+}
+
+// concatRight concatenates l and r, reassociating to the right so that
+// the same suffix always produces the same term.
+func concatRight(l, r Regex) Regex {
+	if c, ok := l.(*concat); ok {
+		return NewConcat(c.l, concatRight(c.r, r))
+	}
+	return NewConcat(l, r)
+}
+
+// DefaultNFAStates is the number of states an NFA caches before it stops
+// caching new ones.
+const DefaultNFAStates = 10000
+
+// NFA is a nondeterministic automaton whose states are the partial
+// derivatives of a regex, built lazily as input is matched. For regexes
+// without complements, it has at most n+1 states, where n is the number
+// of characters in the regex.
+//
+// An NFA caches at most DefaultNFAStates states and their transitions;
+// past that point, states are computed as they are reached, without
+// caching. It is safe for concurrent use, and once a transition is
+// cached, following it takes no locks.
+type NFA struct {
+	alpha alphabet
+	start *nfaState
+
+	mu    sync.Mutex // guards index
+	index map[string]*nfaState
+	max   int
+}
+
+type nfaState struct {
+	r      Regex
+	key    string
+	accept bool
+	cached bool
+	next   []atomic.Value // []*nfaState by class, once known
+}
+
+// NewNFA creates an NFA that accepts the same language as r.
+func NewNFA(r Regex) *NFA {
+	n := &NFA{
+		alpha: newAlphabet(r),
+		index: make(map[string]*nfaState),
+		max:   DefaultNFAStates,
+	}
+	n.start = n.state(locate(r))
+	return n
+}
+
+func (n *NFA) state(r Regex) *nfaState {
+	k := termKey(r)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if st, ok := n.index[k]; ok {
+		return st
+	}
+	st := &nfaState{r: r, key: k, accept: r.Accepting()}
+	if len(n.index) < n.max {
+		st.cached = true
+		st.next = make([]atomic.Value, n.alpha.size())
+		n.index[k] = st
+	}
+	return st
+}
+
+func (n *NFA) step(st *nfaState, c rune) []*nfaState {
+	class := n.alpha.class(c)
+	if st.cached {
+		if next, ok := st.next[class].Load().([]*nfaState); ok {
+			return next
+		}
+	}
+
+	next := []*nfaState{}
+	cached := st.cached
+	for _, p := range PartialDerivatives(st.r, c) {
+		to := n.state(p)
+		next = append(next, to)
+		cached = cached && to.cached
+	}
+
+	// Another goroutine may store the same transition first, but it
+	// finds the same states, so it doesn't matter which store wins.
+	// Transitions to uncached states aren't kept, so they can be freed.
+	if cached {
+		st.next[class].Store(next)
+	}
+	return next
+}
+
+// NumStates returns the number of states cached so far.
+func (n *NFA) NumStates() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.index)
+}
+
+// Match returns true if the string matches the NFA's regex.
+func (n *NFA) Match(s string) bool {
+	current := []*nfaState{n.start}
+	var next []*nfaState
+	seen := make(map[*nfaState]bool)
+	var seenKeys map[string]bool // for uncached states, which aren't unique
+
+	for _, c := range s {
+		next = next[:0]
+		for k := range seen {
+			delete(seen, k)
+		}
+		for k := range seenKeys {
+			delete(seenKeys, k)
+		}
+
+		for _, st := range current {
+			for _, to := range n.step(st, c) {
+				if to.cached {
+					if seen[to] {
+						continue
+					}
+					seen[to] = true
+				} else {
+					if seenKeys == nil {
+						seenKeys = make(map[string]bool)
+					}
+					if seenKeys[to.key] {
+						continue
+					}
+					seenKeys[to.key] = true
+				}
+				next = append(next, to)
+			}
+		}
+
+		if len(next) == 0 {
+			return false
+		}
+		current, next = next, current
+	}
+
+	for _, st := range current {
+		if st.accept {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package dr
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestNFAMatch(t *testing.T) {
+	patterns := []string{
+		"abc*+aad",
+		"(a+b)*abb",
+		"!(a+b*(asd(!d)))+(def)*",
+		"(a+ab)*(b+ba)*",
+		".*a..",
+	}
+	inputs := []string{
+		"",
+		"a",
+		"aad",
+		"abccc",
+		"abb",
+		"ababb",
+		"asdd",
+		"defdef",
+		"abab",
+		"abba",
+		"xxaxy",
+	}
+
+	for _, p := range patterns {
+		r := MustParse(p)
+		n := NewNFA(r)
+		for _, s := range inputs {
+			if got, want := n.Match(s), Match(r, s); got != want {
+				t.Errorf("NewNFA(%q).Match(%q) = %v, want %v", p, s, got, want)
+			}
+		}
+	}
+}
+
+func TestNFAStateBound(t *testing.T) {
+	for _, p := range []string{
+		"(a+b)*abb",
+		"(a+ab)*(b+ba)*",
+		"((a+b)(a+b))*a*b*",
+	} {
+		r := MustParse(p)
+		n := NewNFA(r)
+		n.Match("abababbbaabbabababbbabaaabbbbaaba")
+		n.Match("bbbbbbbbaaaaaaaaaaababababa")
+
+		chars := 0
+		for _, c := range p {
+			if c == 'a' || c == 'b' {
+				chars++
+			}
+		}
+
+		if n.NumStates() > chars+1 {
+			t.Errorf("NewNFA(%q) has %d states, want at most %d", p, n.NumStates(), chars+1)
+		}
+	}
+}
+
+func TestNFACacheBound(t *testing.T) {
+	r := MustParse("(a+ab)*(b+ba)*")
+	n := NewNFA(r)
+	n.max = 2
+
+	for _, s := range []string{"", "a", "ab", "aba", "abba", "abab", "ba", "abbaab", "aabbab"} {
+		for i := 0; i < 2; i++ {
+			if got, want := n.Match(s), Match(r, s); got != want {
+				t.Errorf("Match(%q) = %v, want %v", s, got, want)
+			}
+		}
+	}
+	if n.NumStates() > 2 {
+		t.Errorf("NFA cached %v states, want at most 2", n.NumStates())
+	}
+}
+
+func TestNFAConcurrent(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, p := range genPatterns {
+		r := MustParse(p)
+		n := NewNFA(r)
+
+		inputs := sampleInputs(p, 200, rnd)
+		want := make([]bool, len(inputs))
+		for i, s := range inputs {
+			want[i] = Match(r, s)
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i, s := range inputs {
+					if got := n.Match(s); got != want[i] {
+						t.Errorf("NewNFA(%q).Match(%q) = %v, want %v", p, s, got, want[i])
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,210 @@
+package dr
+
+import "unicode"
+
+// Kind identifies the type of a regex node.
+type Kind int
+
+// The kinds of regex nodes created by this package. Regexes implemented
+// outside of this package have KindOther. Derivatives of regexes with
+// anchors or lookaheads, as returned by Derive, keep their location in
+// the input internally, and otherwise look like the regex they match.
+const (
+	KindOther Kind = iota
+	KindEmpty
+	KindEpsilon
+	KindChar
+	KindAny
+	KindUnion
+	KindConcat
+	KindComp
+	KindKleene
+	KindClass
+	KindAnchor
+	KindLookahead
+	KindIntersection
+)
+
+var kindNames = [...]string{
+	KindOther:        "other",
+	KindEmpty:        "empty",
+	KindEpsilon:      "epsilon",
+	KindChar:         "char",
+	KindAny:          "any",
+	KindUnion:        "union",
+	KindConcat:       "concat",
+	KindComp:         "comp",
+	KindKleene:       "kleene",
+	KindClass:        "class",
+	KindAnchor:       "anchor",
+	KindLookahead:    "lookahead",
+	KindIntersection: "intersection",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+// KindOf returns the kind of r.
+func KindOf(r Regex) Kind {
+	switch r := r.(type) {
+	case *empty:
+		return KindEmpty
+	case *epsilon:
+		return KindEpsilon
+	case *char:
+		return KindChar
+	case *any:
+		return KindAny
+	case *union:
+		return KindUnion
+	case *concat:
+		return KindConcat
+	case *comp:
+		return KindComp
+	case *kleene:
+		return KindKleene
+	case *class:
+		return KindClass
+	case *anchor:
+		return KindAnchor
+	case *lookahead:
+		return KindLookahead
+	case *intersection:
+		return KindIntersection
+	case *located:
+		return KindOf(r.r)
+	default:
+		return KindOther
+	}
+}
+
+// Children returns the operands of r: both sides of a union,
+// intersection or concatenation, or the regex under a complement, Kleene
+// star or lookahead. Other kinds have no children.
+func Children(r Regex) []Regex {
+	switch r := r.(type) {
+	case *union:
+		return []Regex{r.l, r.r}
+	case *intersection:
+		return []Regex{r.l, r.r}
+	case *concat:
+		return []Regex{r.l, r.r}
+	case *comp:
+		return []Regex{r.r}
+	case *kleene:
+		return []Regex{r.r}
+	case *lookahead:
+		return []Regex{r.r}
+	case *located:
+		return Children(r.r)
+	default:
+		return nil
+	}
+}
+
+// CharValue returns the character accepted by a KindChar regex.
+func CharValue(r Regex) (rune, bool) {
+	if c, ok := r.(*char); ok {
+		return c.r, true
+	}
+	return 0, false
+}
+
+// ClassValue returns the table of a KindClass regex and whether it is
+// negated, as they would be passed to NewClass. The table may be shared,
+// and must not be modified.
+func ClassValue(r Regex) (table *unicode.RangeTable, negate bool, ok bool) {
+	if c, ok := r.(*class); ok {
+		return c.table, c.neg, true
+	}
+	return nil, false, false
+}
+
+// AnchorValue returns the anchor matched by a KindAnchor regex, as it is
+// written in a pattern: ^, $, \b or \B.
+func AnchorValue(r Regex) (string, bool) {
+	if a, ok := unlocated(r).(*anchor); ok {
+		return a.String(), true
+	}
+	return "", false
+}
+
+// LookaheadNegated returns true if a KindLookahead regex is negative,
+// like (?!r). The regex it looks for is its only child.
+func LookaheadNegated(r Regex) (negated bool, ok bool) {
+	if l, ok := unlocated(r).(*lookahead); ok {
+		return l.neg, true
+	}
+	return false, false
+}
+
+// unlocated returns the regex wrapped by a derivative which tracks its
+// location in the input, or r itself.
+func unlocated(r Regex) Regex {
+	if l, ok := r.(*located); ok {
+		return l.r
+	}
+	return r
+}
+
+// Walk calls fn on r and its descendants, in depth-first order. If fn
+// returns false, the children of that regex are skipped.
+func Walk(r Regex, fn func(Regex) bool) {
+	if !fn(r) {
+		return
+	}
+	for _, c := range Children(r) {
+		Walk(c, fn)
+	}
+}
+
+// Rewrite transforms r from the bottom up. The children of each regex are
+// rewritten first, then fn is called on the regex rebuilt from the new
+// children, and its result replaces the regex. Rebuilding uses the New
+// functions, so their simplifications apply.
+func Rewrite(r Regex, fn func(Regex) Regex) Regex {
+	children := Children(r)
+	if len(children) == 0 {
+		return fn(r)
+	}
+
+	changed := false
+	for i, c := range children {
+		n := Rewrite(c, fn)
+		if n != c {
+			changed = true
+		}
+		children[i] = n
+	}
+
+	if changed {
+		r = withChildren(r, children)
+	}
+	return fn(r)
+}
+
+// withChildren rebuilds r with new children.
+func withChildren(r Regex, children []Regex) Regex {
+	switch r := r.(type) {
+	case *union:
+		return NewUnion(children[0], children[1])
+	case *intersection:
+		return NewIntersection(children[0], children[1])
+	case *concat:
+		return NewConcat(children[0], children[1])
+	case *comp:
+		return NewComp(children[0])
+	case *kleene:
+		return NewKleene(children[0])
+	case *lookahead:
+		return NewLookahead(children[0], r.neg)
+	case *located:
+		return &located{r: withChildren(r.r, children), prev: r.prev}
+	default:
+		return r
+	}
+}
@@ -0,0 +1,129 @@
+package dr
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestWalk(t *testing.T) {
+	r := MustParse("!(a+b*(asd(!d)))+(def)*")
+
+	var kinds []Kind
+	var chars []rune
+	Walk(r, func(r Regex) bool {
+		kinds = append(kinds, KindOf(r))
+		if c, ok := CharValue(r); ok {
+			chars = append(chars, c)
+		}
+		return KindOf(r) != KindKleene
+	})
+
+	if got, want := string(chars), "aasdd"; got != want {
+		t.Errorf("visited chars %q, want %q", got, want)
+	}
+	if kinds[0] != KindUnion || kinds[1] != KindComp {
+		t.Errorf("visited kinds %v, want union then comp first", kinds)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	r := MustParse("a(b+a)*!a")
+	swapped := Rewrite(r, func(r Regex) Regex {
+		switch c, _ := CharValue(r); c {
+		case 'a':
+			return NewChar('b')
+		case 'b':
+			return NewChar('a')
+		}
+		return r
+	})
+
+	if got, want := swapped.String(), "b(a+b)*!b"; got != want {
+		t.Errorf("Rewrite = %q, want %q", got, want)
+	}
+	if got, want := r.String(), "a(b+a)*!a"; got != want {
+		t.Errorf("original changed to %q, want %q", got, want)
+	}
+
+	same := Rewrite(r, func(r Regex) Regex { return r })
+	if same != r {
+		t.Errorf("identity Rewrite returned a new regex")
+	}
+}
+
+func TestChildren(t *testing.T) {
+	a, b := NewChar('a'), NewChar('b')
+	if got := Children(NewUnion(a, b)); !reflect.DeepEqual(got, []Regex{a, b}) {
+		t.Errorf("Children(union) = %v", got)
+	}
+	if got := Children(a); got != nil {
+		t.Errorf("Children(char) = %v, want nil", got)
+	}
+	if got := KindOf(NewEpsilon()).String(); got != "epsilon" {
+		t.Errorf("KindOf(NewEpsilon()) = %v, want epsilon", got)
+	}
+}
+
+func TestValues(t *testing.T) {
+	if c, ok := CharValue(NewChar('a')); !ok || c != 'a' {
+		t.Errorf("CharValue(a) = %q, %v", c, ok)
+	}
+
+	table, neg, ok := ClassValue(MustParse(`[^a-c]`))
+	if !ok || !neg || !unicode.Is(table, 'b') || unicode.Is(table, 'd') {
+		t.Errorf("ClassValue([^a-c]) = %v, %v, %v", table, neg, ok)
+	}
+	if _, _, ok := ClassValue(NewChar('a')); ok {
+		t.Errorf("ClassValue(a) is ok")
+	}
+
+	for _, p := range []string{"^", "$", `\b`, `\B`} {
+		if got, ok := AnchorValue(MustParse(p)); !ok || got != p {
+			t.Errorf("AnchorValue(%v) = %q, %v", p, got, ok)
+		}
+	}
+	if got, ok := AnchorValue(Derive(MustParse("a$"), "a")); !ok || got != "$" {
+		t.Errorf("AnchorValue of derivative = %q, %v, want $", got, ok)
+	}
+
+	for _, p := range []string{"(?=a)", "(?!a)"} {
+		r := MustParse(p)
+		neg, ok := LookaheadNegated(r)
+		if !ok || neg != (p == "(?!a)") {
+			t.Errorf("LookaheadNegated(%v) = %v, %v", p, neg, ok)
+		}
+		if rebuilt := NewLookahead(Children(r)[0], neg); rebuilt.String() != p {
+			t.Errorf("rebuilt %v as %v", p, rebuilt)
+		}
+	}
+	if _, ok := LookaheadNegated(NewComp(NewChar('a'))); ok {
+		t.Errorf("LookaheadNegated(!a) is ok")
+	}
+}
+
+func TestDerivativeLooksLikeRegex(t *testing.T) {
+	r := MustParse(`a*\b(b+\B.)`)
+	for _, d := range []Regex{Derive(r, "aa"), Memoize(r).Derivative('a')} {
+		inner := d.(*located).r
+		if got, want := KindOf(d), KindOf(inner); got != want {
+			t.Errorf("KindOf(%v) = %v, want %v", d, got, want)
+		}
+		if got, want := Children(d), Children(inner); !reflect.DeepEqual(got, want) {
+			t.Errorf("Children(%v) = %v, want %v", d, got, want)
+		}
+
+		rebuilt := Rewrite(d, func(r Regex) Regex {
+			if c, ok := CharValue(r); ok && c == 'b' {
+				return NewChar('c')
+			}
+			return r
+		})
+		if got, want := rebuilt.String(), `a*\b(\B.+c)`; got != want {
+			t.Errorf("Rewrite(%v) = %v, want %v", d, got, want)
+		}
+		if _, ok := rebuilt.(*located); !ok {
+			t.Errorf("Rewrite(%v) lost its location", d)
+		}
+	}
+}
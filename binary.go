@@ -0,0 +1,186 @@
+package dr
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"unicode"
+)
+
+// The binary DFA format is:
+//
+//	magic      "drDFA"
+//	version    byte
+//	classes    uvarint count, then each class boundary as a uvarint
+//	           delta from the previous one
+//	states     uvarint count
+//	accept     one bit per state, least significant bit first
+//	transition uvarint per state per class
+//	checksum   CRC-32 (IEEE) of everything before it, little endian
+const (
+	binaryMagic   = "drDFA"
+	binaryVersion = 1
+)
+
+var errCorrupt = errors.New("corrupt DFA")
+
+var (
+	_ encoding.BinaryMarshaler   = &DFA{}
+	_ encoding.BinaryUnmarshaler = &DFA{}
+)
+
+// MarshalBinary encodes the DFA in a compact, versioned format. The
+// derivatives labeling each state are not included, so a decoded DFA
+// can match but has no regex to describe it. A DFA from Explore which
+// stopped at its state limit can't be encoded, and returns ErrIncomplete.
+//
+// This allows DFAs to be compiled ahead of time and embedded in a
+// program:
+//
+//	//go:embed ident.dfa
+//	var identDFA []byte
+//
+//	var ident = new(dr.DFA)
+//
+//	func init() {
+//		if err := ident.UnmarshalBinary(identDFA); err != nil {
+//			panic(err)
+//		}
+//	}
+func (d *DFA) MarshalBinary() ([]byte, error) {
+	if !d.complete() {
+		return nil, ErrIncomplete
+	}
+
+	var buf bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+
+	putUvarint := func(x uint64) {
+		n := binary.PutUvarint(tmp[:], x)
+		buf.Write(tmp[:n])
+	}
+
+	buf.WriteString(binaryMagic)
+	buf.WriteByte(binaryVersion)
+
+	putUvarint(uint64(len(d.alpha)))
+	prev := rune(0)
+	for _, b := range d.alpha {
+		putUvarint(uint64(b - prev))
+		prev = b
+	}
+
+	putUvarint(uint64(len(d.accept)))
+
+	bits := make([]byte, (len(d.accept)+7)/8)
+	for i, a := range d.accept {
+		if a {
+			bits[i/8] |= 1 << uint(i%8)
+		}
+	}
+	buf.Write(bits)
+
+	for _, t := range d.trans {
+		putUvarint(uint64(t))
+	}
+
+	return appendChecksum(buf.Bytes()), nil
+}
+
+func appendChecksum(b []byte) []byte {
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(b))
+	return append(b, sum[:]...)
+}
+
+// UnmarshalBinary decodes a DFA written by MarshalBinary, replacing the
+// contents of d. Malformed or corrupted data results in an error.
+func (d *DFA) UnmarshalBinary(data []byte) error {
+	header := len(binaryMagic) + 1
+	if len(data) < header+4 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return errors.New("not a DFA")
+	}
+	if v := data[len(binaryMagic)]; v != binaryVersion {
+		return fmt.Errorf("unsupported DFA version %v", v)
+	}
+
+	body, sum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(sum) {
+		return fmt.Errorf("%v: checksum mismatch", errCorrupt)
+	}
+
+	r := bytes.NewReader(body[header:])
+	uvarint := func(max uint64) (uint64, error) {
+		x, err := binary.ReadUvarint(r)
+		if err != nil || x > max {
+			return 0, errCorrupt
+		}
+		return x, nil
+	}
+
+	// Every count is bounded by the remaining input, since each
+	// element takes at least one byte (or bit, for accept).
+	numBounds, err := uvarint(uint64(r.Len()))
+	if err != nil {
+		return err
+	}
+
+	alpha := make(alphabet, numBounds)
+	prev := uint64(0)
+	for i := range alpha {
+		delta, err := uvarint(unicode.MaxRune + 1)
+		if err != nil {
+			return err
+		}
+		prev += delta
+		if delta == 0 || prev > unicode.MaxRune+1 {
+			return errCorrupt
+		}
+		alpha[i] = rune(prev)
+	}
+
+	numStates, err := uvarint(uint64(r.Len()) * 8)
+	if err != nil {
+		return err
+	}
+	if numStates == 0 {
+		return errCorrupt
+	}
+
+	bits := make([]byte, (numStates+7)/8)
+	if _, err := io.ReadFull(r, bits); err != nil {
+		return errCorrupt
+	}
+	accept := make([]bool, numStates)
+	for i := range accept {
+		accept[i] = bits[i/8]&(1<<uint(i%8)) != 0
+	}
+
+	numTrans := numStates * uint64(alpha.size())
+	if numTrans > uint64(r.Len()) {
+		return errCorrupt
+	}
+	trans := make([]int, numTrans)
+	for i := range trans {
+		t, err := uvarint(numStates - 1)
+		if err != nil {
+			return err
+		}
+		trans[i] = int(t)
+	}
+
+	if r.Len() != 0 {
+		return errCorrupt
+	}
+
+	*d = DFA{
+		alpha:  alpha,
+		accept: accept,
+		trans:  trans,
+	}
+	return nil
+}
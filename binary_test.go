@@ -0,0 +1,73 @@
+package dr
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestDFABinaryRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, p := range genPatterns {
+		r := MustParse(p)
+		data, err := MustCompile(r, 0).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var d DFA
+		if err := d.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(%q): %v", p, err)
+		}
+
+		for _, s := range sampleInputs(p, 200, rnd) {
+			if got, want := d.Match(s), Match(r, s); got != want {
+				t.Errorf("decoded %q: Match(%q) = %v, want %v", p, s, got, want)
+			}
+		}
+
+		again, err := d.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("re-encoding %q gave different bytes", p)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteGo(&buf, &d, GoOptions{Package: "p", Func: "F"}); err != nil {
+			t.Errorf("WriteGo of decoded %q: %v", p, err)
+		}
+	}
+}
+
+func TestDFABinaryCorrupt(t *testing.T) {
+	data, err := MustCompile(MustParse("!(a+b*(asd(!d)))+(def)*"), 0).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d DFA
+	for i := range data {
+		if err := d.UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("truncated to %v bytes: got no error", i)
+		}
+
+		corrupt := append([]byte(nil), data...)
+		corrupt[i] ^= 0x10
+		if err := d.UnmarshalBinary(corrupt); err == nil {
+			t.Errorf("flipped bit in byte %v: got no error", i)
+		}
+	}
+
+	// Garbage with a valid header and checksum must not panic.
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		body := []byte(binaryMagic + "\x01")
+		for j := rnd.Intn(32); j > 0; j-- {
+			body = append(body, byte(rnd.Intn(256)))
+		}
+		d.UnmarshalBinary(appendChecksum(body))
+	}
+}
@@ -0,0 +1,178 @@
+package dr
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseBytes parses a regex which matches bytes rather than characters,
+// for use with MatchBytes. Each character in the pattern stands for the
+// byte with the same value, so it must be at most \xFF; '.' and negated
+// classes match any other byte, and classes are limited to \x00-\xFF.
+//
+// To match the UTF-8 encoding of a pattern instead, use NewUTF8.
+func ParseBytes(s string) (Regex, error) {
+	return ParseWithOptions(s, Options{Bytes: true})
+}
+
+// MatchBytes is like Match, but takes the derivative with respect to each
+// byte of b, rather than each character of a string. It is meant for
+// regexes from ParseBytes and NewUTF8.
+func MatchBytes(r Regex, b []byte) bool {
+	r = locate(r)
+	for _, c := range b {
+		r = r.Derivative(rune(c))
+	}
+	return r.Accepting()
+}
+
+// MatchBytes is like Match, but steps the DFA on each byte of b rather
+// than each character. It is meant for DFAs compiled from regexes from
+// ParseBytes and NewUTF8.
+func (d *DFA) MatchBytes(b []byte) bool {
+	i := 0
+	for j, c := range b {
+		if !d.Expanded(i) {
+			return MatchBytes(d.terms[i], b[j:])
+		}
+		i = d.Next(i, rune(c))
+	}
+	return d.accept[i]
+}
+
+var allBytes = [][2]rune{{0, 0xFF}}
+
+// clipBytes limits any and the classes in r to bytes.
+func clipBytes(r Regex) Regex {
+	return Rewrite(r, func(r Regex) Regex {
+		switch r := r.(type) {
+		case *any:
+			return NewClass(rangesTable(allBytes), false)
+		case *class:
+			ranges := intersectRanges(r.ranges(), allBytes)
+			if len(ranges) == 0 {
+				return NewEmpty()
+			}
+			if !r.neg && equalRanges(ranges, r.ranges()) {
+				return r
+			}
+			return NewClass(rangesTable(ranges), false)
+		default:
+			return r
+		}
+	})
+}
+
+// intersectRanges returns the ranges in both of the sorted ranges a and b.
+func intersectRanges(a, b [][2]rune) [][2]rune {
+	var out [][2]rune
+	for len(a) != 0 && len(b) != 0 {
+		lo, hi := a[0][0], a[0][1]
+		if b[0][0] > lo {
+			lo = b[0][0]
+		}
+		if b[0][1] < hi {
+			hi = b[0][1]
+		}
+		if lo <= hi {
+			out = append(out, [2]rune{lo, hi})
+		}
+		if a[0][1] < b[0][1] {
+			a = a[1:]
+		} else {
+			b = b[1:]
+		}
+	}
+	return out
+}
+
+// NewUTF8 converts r to a regex over bytes, which matches the UTF-8
+// encodings of the strings r matches. Characters become sequences of
+// bytes, and '.' and classes become unions of byte sequences covering
+// their valid encodings, so the result can be compiled to a DFA that runs
+// directly over a []byte with MatchBytes.
+//
+// A complement matches any bytes that aren't the encoding of a string
+// in its operand, including invalid UTF-8.
+func NewUTF8(r Regex) Regex {
+	return Rewrite(r, func(r Regex) Regex {
+		switch r := r.(type) {
+		case *char:
+			return utf8Ranges(r.r, r.r)
+		case *any:
+			return utf8Ranges(0, unicode.MaxRune)
+		case *class:
+			u := NewEmpty()
+			for _, rng := range r.ranges() {
+				u = NewUnion(u, utf8Ranges(rng[0], rng[1]))
+			}
+			return u
+		default:
+			return r
+		}
+	})
+}
+
+// utf8Ranges returns a regex over bytes matching the UTF-8 encoding of
+// any character from lo to hi. Surrogates, which have no encoding, are
+// skipped.
+//
+// The range is split until each piece is a sequence of byte ranges, as in
+// RE2, so \u{80}-\u{10FFFF} becomes [\xC2-\xDF][\x80-\xBF] and so on.
+func utf8Ranges(lo, hi rune) Regex {
+	const surrogateMin, surrogateMax = 0xD800, 0xDFFF
+
+	switch {
+	case lo > hi:
+		return NewEmpty()
+	case lo < surrogateMin && hi > surrogateMax:
+		return NewUnion(utf8Ranges(lo, surrogateMin-1), utf8Ranges(surrogateMax+1, hi))
+	case lo >= surrogateMin && lo <= surrogateMax:
+		return utf8Ranges(surrogateMax+1, hi)
+	case hi >= surrogateMin && hi <= surrogateMax:
+		return utf8Ranges(lo, surrogateMin-1)
+	}
+
+	// Split where the length of the encoding changes.
+	for _, max := range []rune{0x7F, 0x7FF, 0xFFFF} {
+		if lo <= max && hi > max {
+			return NewUnion(utf8Ranges(lo, max), utf8Ranges(max+1, hi))
+		}
+	}
+
+	if hi < utf8.RuneSelf {
+		return byteRange(byte(lo), byte(hi))
+	}
+
+	// Split until every continuation byte after the first differing byte
+	// covers its whole range, so the encodings of lo and hi bound a
+	// sequence of byte ranges.
+	for i := uint(1); i < utf8.UTFMax; i++ {
+		m := rune(1)<<(6*i) - 1
+		if lo&^m != hi&^m {
+			if lo&m != 0 {
+				return NewUnion(utf8Ranges(lo, lo|m), utf8Ranges((lo|m)+1, hi))
+			}
+			if hi&m != m {
+				return NewUnion(utf8Ranges(lo, hi&^m-1), utf8Ranges(hi&^m, hi))
+			}
+		}
+	}
+
+	var a, b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(a[:], lo)
+	utf8.EncodeRune(b[:], hi)
+
+	seq := byteRange(a[n-1], b[n-1])
+	for i := n - 2; i >= 0; i-- {
+		seq = NewConcat(byteRange(a[i], b[i]), seq)
+	}
+	return seq
+}
+
+func byteRange(lo, hi byte) Regex {
+	if lo == hi {
+		return NewChar(rune(lo))
+	}
+	return NewClass(rangesTable([][2]rune{{rune(lo), rune(hi)}}), false)
+}
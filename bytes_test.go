@@ -0,0 +1,140 @@
+package dr
+
+import (
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		fails   []string
+	}{
+		{`\xFF\x00`, []string{"\xff\x00"}, []string{"ÿ\x00"}},
+		{`.*`, []string{"", "\xff\xfe", "é"}, nil},
+		{`..`, []string{"é", "\x80\x80"}, []string{"a", "abc"}},
+		{`[^\x00-\x7F]*`, []string{"\x80\xff", "é"}, []string{"a"}},
+		{`\W`, []string{"\xff", "-"}, []string{"a"}},
+		{`\pL`, []string{"a", "\xe9"}, []string{"1", "\xd7"}},
+		{`\377\0`, []string{"\xff\x00"}, nil},
+	}
+
+	for _, test := range tests {
+		r, err := ParseBytes(test.pattern)
+		if err != nil {
+			t.Errorf("ParseBytes(%q) returned %v", test.pattern, err)
+			continue
+		}
+		for _, s := range test.matches {
+			if !MatchBytes(r, []byte(s)) {
+				t.Errorf("%q does not match %q", test.pattern, s)
+			}
+		}
+		for _, s := range test.fails {
+			if MatchBytes(r, []byte(s)) {
+				t.Errorf("%q matches %q", test.pattern, s)
+			}
+		}
+	}
+}
+
+func TestParseBytesErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		offset  int
+		problem string
+	}{
+		{"aā", 1, "'ā' is not a byte"},
+		{`a\u{100}`, 1, `'Ā' is not a byte`},
+		{`(\u{2028})`, 1, `'\u{2028}' is not a byte`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseBytes(test.pattern)
+		e, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseBytes(%q) returned %v, want *SyntaxError", test.pattern, err)
+			continue
+		}
+		if e.Offset != test.offset || e.Problem != test.problem {
+			t.Errorf("ParseBytes(%q) error %q at %v, want %q at %v",
+				test.pattern, e.Problem, e.Offset, test.problem, test.offset)
+		}
+	}
+}
+
+func TestParseBytesFold(t *testing.T) {
+	r, err := ParseWithOptions("ké", Options{Bytes: true, CaseInsensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.String(), `[Kk][Éé]`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestUTF8Ranges(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	bounds := []rune{0, 0x7F, 0x80, 0x7FF, 0x800, 0xD7FF, 0xE000, 0xFFFF, 0x10000, 0x10FFFF}
+
+	for i := 0; i < 200; i++ {
+		lo := bounds[rnd.Intn(len(bounds))] + rune(rnd.Intn(3)) - 1
+		if lo < 0 || lo > 0x10FFFF {
+			lo = 0
+		}
+		hi := lo + rune(rnd.Intn(0x20000))
+		if hi > 0x10FFFF {
+			hi = 0x10FFFF
+		}
+		r := utf8Ranges(lo, hi)
+
+		for j := 0; j < 50; j++ {
+			c := rune(rnd.Intn(0x110000))
+			if j%2 == 0 {
+				c = lo + rune(rnd.Intn(int(hi-lo)+1))
+			}
+			want := lo <= c && c <= hi && utf8.ValidRune(c)
+			if got := MatchBytes(r, []byte(string(c))); got != want && utf8.ValidRune(c) {
+				t.Fatalf("utf8Ranges(%U, %U) matching %U = %v, want %v", lo, hi, c, got, want)
+			}
+		}
+	}
+}
+
+func TestNewUTF8(t *testing.T) {
+	// Complements also match invalid UTF-8, which isn't the encoding of
+	// anything their operand matches.
+	tests := []struct {
+		pattern string
+		invalid bool
+	}{
+		{"abc", false},
+		{"é*ü", false},
+		{".", false},
+		{".*😀.*", false},
+		{`\pL+\d`, false},
+		{`[^a-z]`, false},
+		{`!(a.*)`, true},
+	}
+	inputs := []string{"", "a", "abc", "éü", "ü", "😀", "x😀", "Жx1", "ab", "\xff", "a\xff", "Ω5", "-"}
+
+	for _, test := range tests {
+		r := MustParse(test.pattern)
+		u := NewUTF8(r)
+		d := MustCompile(u, 0)
+		for _, s := range inputs {
+			want := Match(r, s) && utf8.ValidString(s)
+			if !utf8.ValidString(s) {
+				want = test.invalid
+			}
+			if got := MatchBytes(u, []byte(s)); got != want {
+				t.Errorf("NewUTF8(%q) matching %q = %v, want %v", test.pattern, s, got, want)
+			}
+			if got := d.MatchBytes([]byte(s)); got != want {
+				t.Errorf("DFA for NewUTF8(%q) matching %q = %v, want %v", test.pattern, s, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package dr
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type class struct {
+	table *unicode.RangeTable
+	neg   bool
+	name  string
+}
+
+// NewClass creates a regex that accepts any single character in the
+// table, or if negate is true, any character not in the table.
+func NewClass(table *unicode.RangeTable, negate bool) Regex {
+	c := &class{
+		table: table,
+		neg:   negate,
+	}
+	c.name = c.bracket()
+	return c
+}
+
+// ParseClass parses a character class, in any of the forms accepted by
+// Parse:
+//
+//	\d, \s, \w      ASCII digits, whitespace and word characters
+//	\D, \S, \W      their negations
+//	\p{Greek}, \pL  Unicode categories and scripts
+//	\P{Lu}, \PL     their negations
+//	[a-z\d_]        brackets of characters, ranges and classes
+//	[^a-z]          the negation of a bracket
+func ParseClass(s string) (Regex, error) {
+	c, err := parseClass(s)
+	if err != nil {
+		return nil, newSyntaxError(s, err.offset, err.problem)
+	}
+	return c, nil
+}
+
+// perlTables are the ASCII-only Perl classes, as in RE2.
+var perlTables = map[byte]*unicode.RangeTable{
+	'd': rangesTable([][2]rune{{'0', '9'}}),
+	's': rangesTable([][2]rune{{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}}),
+	'w': rangesTable([][2]rune{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}),
+}
+
+// classError is a problem found at a byte offset in a class.
+type classError struct {
+	offset  int
+	problem string
+}
+
+func (e *classError) Error() string {
+	return e.problem
+}
+
+func parseClass(s string) (*class, *classError) {
+	if strings.HasPrefix(s, "[") {
+		return parseBracket(s)
+	}
+
+	table, neg, n, err := parseClassEscape(s)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(s) {
+		return nil, &classError{n, "unexpected characters after class"}
+	}
+
+	return &class{
+		table: table,
+		neg:   neg,
+		name:  s,
+	}, nil
+}
+
+// parseClassEscape parses a class escape at the start of s, returning
+// its table, whether it is negated, and its length in bytes.
+func parseClassEscape(s string) (*unicode.RangeTable, bool, int, *classError) {
+	if len(s) < 2 || s[0] != '\\' {
+		return nil, false, 0, &classError{0, "expected class escape"}
+	}
+
+	switch c := s[1]; c {
+	case 'd', 's', 'w', 'D', 'S', 'W':
+		return perlTables[c|0x20], c < 'a', 2, nil
+
+	case 'p', 'P':
+		var name string
+		n := 3
+		switch {
+		case len(s) < 3:
+			return nil, false, 0, &classError{2, "expected Unicode class name"}
+		case s[2] == '{':
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return nil, false, 0, &classError{len(s), "expected '}'"}
+			}
+			name, n = s[3:end], end+1
+		default:
+			_, size := utf8.DecodeRuneInString(s[2:])
+			name, n = s[2:2+size], 2+size
+		}
+
+		table := unicode.Categories[name]
+		if table == nil {
+			table = unicode.Scripts[name]
+		}
+		if table == nil {
+			return nil, false, 0, &classError{0, fmt.Sprintf("unknown Unicode class '%v'", s[:n])}
+		}
+		return table, c == 'P', n, nil
+
+	default:
+		return nil, false, 0, &classError{0, fmt.Sprintf("invalid class escape '%v'", s[:2])}
+	}
+}
+
+func parseBracket(s string) (*class, *classError) {
+	i := 1
+	neg := false
+	if i < len(s) && s[i] == '^' {
+		neg = true
+		i++
+	}
+
+	var ranges [][2]rune
+
+	// char reads a single, possibly escaped, character at i.
+	char := func() (rune, *classError) {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c != '\\' {
+			i += size
+			return c, nil
+		}
+
+		next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+		if next >= utf8.RuneSelf || unicode.IsLetter(next) || unicode.IsDigit(next) {
+			r, n, err := unescape(s[i:])
+			if err != nil {
+				err.offset += i
+				return 0, err
+			}
+			i += n
+			return r, nil
+		}
+		i += size + nextSize
+		return next, nil
+	}
+
+	for i < len(s) && s[i] != ']' {
+		if strings.HasPrefix(s[i:], `\`) && i+1 < len(s) && strings.IndexByte("dswDSWpP", s[i+1]) >= 0 {
+			table, tableNeg, n, err := parseClassEscape(s[i:])
+			if err != nil {
+				err.offset += i
+				return nil, err
+			}
+			rs := tableRanges(table)
+			if tableNeg {
+				rs = invertRanges(rs)
+			}
+			ranges = append(ranges, rs...)
+			i += n
+			continue
+		}
+
+		start := i
+		lo, err := char()
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+
+		if strings.HasPrefix(s[i:], "-") && i+1 < len(s) && s[i+1] != ']' {
+			i++
+			if hi, err = char(); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, &classError{start, fmt.Sprintf("invalid range '%v'", s[start:i])}
+			}
+		}
+
+		ranges = append(ranges, [2]rune{lo, hi})
+	}
+
+	if i >= len(s) {
+		return nil, &classError{len(s), "expected ']'"}
+	}
+	if i+1 != len(s) {
+		return nil, &classError{i + 1, "unexpected characters after class"}
+	}
+	if len(ranges) == 0 {
+		return nil, &classError{0, "empty class"}
+	}
+
+	return &class{
+		table: rangesTable(ranges),
+		neg:   neg,
+		name:  escapeBracket(s),
+	}, nil
+}
+
+// escapeBracket returns the source of a bracket class with its
+// non-printable characters escaped, so it is safe to print.
+func escapeBracket(s string) string {
+	var buf bytes.Buffer
+	for _, r := range s {
+		if unicode.IsPrint(r) {
+			buf.WriteRune(r)
+		} else {
+			writeClassRune(&buf, r)
+		}
+	}
+	return buf.String()
+}
+
+func (c *class) String() string {
+	return c.name
+}
+
+// bracket returns the class in bracket syntax.
+func (c *class) bracket() string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	if c.neg {
+		buf.WriteByte('^')
+	}
+	for _, r := range tableRanges(c.table) {
+		writeClassRune(&buf, r[0])
+		if r[1] > r[0] {
+			if r[1] > r[0]+1 {
+				buf.WriteByte('-')
+			}
+			writeClassRune(&buf, r[1])
+		}
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+func writeClassRune(buf *bytes.Buffer, r rune) {
+	if strings.ContainsRune(`\]-^[`, r) {
+		buf.WriteByte('\\')
+	}
+	buf.WriteString(escapeRune(r))
+}
+
+func (c *class) matches(r rune) bool {
+	return unicode.Is(c.table, r) != c.neg
+}
+
+// ranges returns the sorted ranges of characters accepted by the class.
+func (c *class) ranges() [][2]rune {
+	rs := tableRanges(c.table)
+	if c.neg {
+		rs = invertRanges(rs)
+	}
+	return rs
+}
+
+// Derivative returns Epsilon if r is in the class,
+// otherwise Empty.
+func (c *class) Derivative(r rune) Regex {
+	if c.matches(r) {
+		return NewEpsilon()
+	}
+	return NewEmpty()
+}
+
+// Accepting returns false.
+func (*class) Accepting() bool {
+	return false
+}
+
+// tableRanges returns the sorted, merged ranges of a table.
+func tableRanges(t *unicode.RangeTable) [][2]rune {
+	var rs [][2]rune
+	for _, r := range t.R16 {
+		rs = appendStride(rs, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range t.R32 {
+		rs = appendStride(rs, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return mergeRanges(rs)
+}
+
+func appendStride(rs [][2]rune, lo, hi, stride rune) [][2]rune {
+	if stride == 1 {
+		return append(rs, [2]rune{lo, hi})
+	}
+	for c := lo; c <= hi; c += stride {
+		rs = append(rs, [2]rune{c, c})
+	}
+	return rs
+}
+
+// mergeRanges sorts ranges and merges those that overlap or touch.
+func mergeRanges(rs [][2]rune) [][2]rune {
+	sort.Slice(rs, func(i, j int) bool { return rs[i][0] < rs[j][0] })
+
+	var merged [][2]rune
+	for _, r := range rs {
+		if n := len(merged); n != 0 && r[0] <= merged[n-1][1]+1 {
+			if r[1] > merged[n-1][1] {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// invertRanges returns the ranges of runes not in the given sorted ranges.
+func invertRanges(ranges [][2]rune) [][2]rune {
+	var inv [][2]rune
+	next := rune(0)
+	for _, r := range ranges {
+		if r[0] > next {
+			inv = append(inv, [2]rune{next, r[0] - 1})
+		}
+		next = r[1] + 1
+	}
+	if next <= unicode.MaxRune {
+		inv = append(inv, [2]rune{next, unicode.MaxRune})
+	}
+	return inv
+}
+
+// rangesTable builds a table from ranges.
+func rangesTable(rs [][2]rune) *unicode.RangeTable {
+	t := &unicode.RangeTable{}
+	for _, r := range mergeRanges(rs) {
+		if r[1] <= 0xFFFF {
+			t.R16 = append(t.R16, unicode.Range16{Lo: uint16(r[0]), Hi: uint16(r[1]), Stride: 1})
+			if r[1] <= unicode.MaxLatin1 {
+				t.LatinOffset++
+			}
+			continue
+		}
+		if r[0] <= 0xFFFF {
+			t.R16 = append(t.R16, unicode.Range16{Lo: uint16(r[0]), Hi: 0xFFFF, Stride: 1})
+			r[0] = 0x10000
+		}
+		t.R32 = append(t.R32, unicode.Range32{Lo: uint32(r[0]), Hi: uint32(r[1]), Stride: 1})
+	}
+	return t
+}
@@ -0,0 +1,130 @@
+package dr
+
+import (
+	"math/rand"
+	"testing"
+	"unicode"
+)
+
+func TestClassMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		yes     string
+		no      string
+	}{
+		{`\d`, "09", "a٣ "},
+		{`\D`, "a٣ ", "09"},
+		{`\w`, "azAZ09_", "-é "},
+		{`\W`, "-é ", "azAZ09_"},
+		{`\s`, " \t\n\r\f", "a\v"},
+		{`\S`, "a\v", " \t"},
+		{`\p{Greek}`, "αΩ", "aЖ"},
+		{`\P{Greek}`, "aЖ", "αΩ"},
+		{`\p{Lu}`, "AΩЖ", "aω1"},
+		{`\PL`, "1 -", "aΩ"},
+		{`[a-c\d_]`, "abc05_", "dA-"},
+		{`[^a-c]`, "dA-", "abc"},
+		{`[\]\-\\^]`, `]-\^`, "a["},
+		{`[\D]`, "a-", "05"},
+		{`[^\p{L}\d]`, "-_ ", "aΩ5"},
+		{`[é-ü]`, "éü", "a"},
+	}
+
+	for _, test := range tests {
+		r, err := Parse(test.pattern)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %v", test.pattern, err)
+			continue
+		}
+
+		for _, c := range test.yes {
+			if !Match(r, string(c)) {
+				t.Errorf("%v does not match %q", test.pattern, c)
+			}
+		}
+		for _, c := range test.no {
+			if Match(r, string(c)) {
+				t.Errorf("%v matches %q", test.pattern, c)
+			}
+		}
+
+		if got := r.String(); got != test.pattern {
+			t.Errorf("Parse(%q).String() = %q", test.pattern, got)
+		}
+	}
+}
+
+func TestClassInRegex(t *testing.T) {
+	r := MustParse(`[a-z_][a-z\d_]*(\.\w\w*)*`)
+	d := MustCompile(r, 0)
+
+	for s, want := range map[string]bool{
+		"foo":         true,
+		"foo.bar_2.x": true,
+		"_":           true,
+		"2foo":        false,
+		"foo.":        false,
+		"foo..bar":    false,
+	} {
+		if got := Match(r, s); got != want {
+			t.Errorf("Match(%q) = %v, want %v", s, got, want)
+		}
+		if got := d.Match(s); got != want {
+			t.Errorf("DFA Match(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestNewClass(t *testing.T) {
+	r := NewClass(rangesTable([][2]rune{{'a', 'c'}, {'-', '-'}, {'x', 'y'}}), true)
+	if got, want := r.String(), `[^\-a-cxy]`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	parsed := MustParse(r.String())
+	for c := rune(0); c < 0x300; c++ {
+		if got, want := Match(parsed, string(c)), Match(r, string(c)); got != want {
+			t.Errorf("Match(%q) = %v after parsing, want %v", c, got, want)
+		}
+	}
+
+	greek := NewClass(unicode.Greek, false)
+	if !Match(greek, "λ") || Match(greek, "l") {
+		t.Errorf("NewClass(unicode.Greek) does not match Greek")
+	}
+}
+
+func TestClassErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		offset  int
+		problem string
+	}{
+		{`ab\p{Foo}`, 2, `unknown Unicode class '\p{Foo}'`},
+		{`\pX`, 0, `unknown Unicode class '\pX'`},
+		{`a[z-a]`, 2, `invalid range 'z-a'`},
+		{`[a\q]`, 2, `invalid escape '\q'`},
+		{`[]`, 0, "empty class"},
+		{`[ab`, 3, "unclosed '['"},
+		{`a\p{L`, 5, "unexpected end of pattern"},
+		{`[\p{Foo}]`, 1, `unknown Unicode class '\p{Foo}'`},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.pattern)
+		e, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Parse(%q) returned %v, want *SyntaxError", test.pattern, err)
+			continue
+		}
+		if e.Offset != test.offset || e.Problem != test.problem {
+			t.Errorf("Parse(%q) error %q at %v, want %q at %v", test.pattern, e.Problem, e.Offset, test.problem, test.offset)
+		}
+	}
+}
+
+var randomClasses = []string{`\d`, `\W`, `\p{Greek}`, `\PL`, `[a-c\s]`, `[^\]x]`, "[\n\x01]", "[^\x00-\t\u00ad]"}
+
+func randomClass(rnd *rand.Rand) Regex {
+	return MustParse(randomClasses[rnd.Intn(len(randomClasses))])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jakebailey/dr"
+)
+
+// graphCmd returns a command which explores the derivatives of a pattern
+// and writes them with the given function.
+func graphCmd(name string, write func(w io.Writer, d *dr.DFA, showDead bool) error) func(args []string) error {
+	return func(args []string) error {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		maxStates := fs.Int("max", dr.DefaultDOTMaxStates, "maximum number of states to expand (0 for no limit)")
+		alphabet := fs.String("alphabet", "", "only follow these characters")
+		showDead := fs.Bool("dead", false, "show the state which matches nothing")
+		fs.Usage = func() {}
+
+		args, err := parseInterspersed(fs, args)
+		if err != nil {
+			return errUsage{}
+		}
+		rs, err := parsePatterns(args, 1)
+		if err != nil {
+			return err
+		}
+
+		d, complete := dr.Explore(rs[0], dr.ExploreOptions{
+			MaxStates: *maxStates,
+			Alphabet:  *alphabet,
+		})
+		if !complete {
+			fmt.Fprintf(os.Stderr, "dr: warning: stopped after %v states; raise -max to see more\n", *maxStates)
+		}
+
+		w := bufio.NewWriter(os.Stdout)
+		if err := write(w, d, *showDead); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+}
+
+func writeDOT(w io.Writer, d *dr.DFA, showDead bool) error {
+	return d.WriteDOT(w, showDead)
+}
+
+// writeMermaid writes the DFA as a Mermaid state diagram, with accepting
+// states leading to the end state.
+func writeMermaid(w io.Writer, d *dr.DFA, showDead bool) error {
+	fmt.Fprintln(w, "stateDiagram-v2")
+	fmt.Fprintln(w, "\tdirection LR")
+
+	for i := 0; i < d.NumStates(); i++ {
+		if showDead || !d.Dead(i) {
+			fmt.Fprintf(w, "\ts%v : %v\n", i, mermaidEscape(d.State(i).String()))
+		}
+	}
+
+	fmt.Fprintln(w, "\t[*] --> s0")
+	for i := 0; i < d.NumStates(); i++ {
+		if !showDead && d.Dead(i) {
+			continue
+		}
+		if d.Accepting(i) {
+			fmt.Fprintf(w, "\ts%v --> [*]\n", i)
+		}
+		for _, t := range d.Transitions(i) {
+			if showDead || !d.Dead(t.To) {
+				fmt.Fprintf(w, "\ts%v --> s%v : %v\n", i, t.To, mermaidEscape(t.String()))
+			}
+		}
+	}
+	return nil
+}
+
+// mermaidEscape replaces the characters which Mermaid treats as syntax in
+// labels with entity codes.
+func mermaidEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '#', ';', ':', '"', '<', '>', '{', '}', '%':
+			fmt.Fprintf(&b, "#%v;", c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// writeTable writes the DFA as a table with a row per state, listing the
+// derivative each state stands for and where each set of characters
+// leads.
+func writeTable(w io.Writer, d *dr.DFA, showDead bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "state\taccepting\tterm\ttransitions")
+
+	for i := 0; i < d.NumStates(); i++ {
+		if !showDead && d.Dead(i) {
+			continue
+		}
+
+		var trans []string
+		for _, t := range d.Transitions(i) {
+			if showDead || !d.Dead(t.To) {
+				trans = append(trans, fmt.Sprintf("%v → %v", t, t.To))
+			}
+		}
+		desc := strings.Join(trans, ", ")
+		if !d.Expanded(i) {
+			desc = "(not explored)"
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", i, d.Accepting(i), d.State(i), desc)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,250 @@
+// Command dr explores and analyzes dr regexes.
+//
+// Usage:
+//
+//	dr [repl [pattern]]
+//	dr equiv A B
+//	dr subset A B
+//	dr empty A
+//	dr witness A
+//	dr count [-n length] [-max states] A
+//	dr dot|mermaid|table [-max states] [-alphabet chars] [-dead] A
+//
+// With no arguments, or with repl, dr reads a pattern, unless one is
+// given, then reads lines of input and takes the derivative with respect
+// to each character in turn. After each character, it shows the current
+// derivative, whether it is accepting, whether it is dead (matches
+// nothing), and its size in nodes. Lines starting with a colon are
+// commands:
+//
+//	:reset      go back to the original pattern
+//	:back       undo the last derivative
+//	:witness    show a shortest string matched by the current derivative
+//	:pattern p  start again with a new pattern
+//	:help       list the commands
+//	:quit       exit
+//
+// The other commands check properties of patterns:
+//
+//	equiv    A and B match the same strings
+//	subset   every string matched by A is matched by B
+//	empty    A matches nothing
+//	witness  print a shortest string matched by A
+//	count    print how many strings of each length up to -n A matches
+//
+// When a check fails, a shortest counterexample is printed. The exit
+// status is 0 if the check holds, 1 if it doesn't, and 2 if an error
+// occurred, such as a pattern failing to parse.
+//
+// The dot, mermaid and table commands print the automaton formed by the
+// derivatives of A, as a Graphviz graph, a Mermaid state diagram, or a
+// table of transitions, with each state labeled by its derivative. Only
+// the first -max states are expanded, with a warning if there are more,
+// and -alphabet restricts the characters followed. The state matching
+// nothing is hidden unless -dead is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jakebailey/dr"
+)
+
+const usage = `usage:
+	dr [repl [pattern]]
+	dr equiv A B
+	dr subset A B
+	dr empty A
+	dr witness A
+	dr count [-n length] [-max states] A
+	dr dot|mermaid|table [-max states] [-alphabet chars] [-dead] A
+`
+
+// errFailed is returned by a command when the property it checks doesn't
+// hold, after it has printed why.
+type errFailed struct{}
+
+func (errFailed) Error() string { return "check failed" }
+
+// errUsage is returned by a command given the wrong arguments.
+type errUsage struct{}
+
+func (errUsage) Error() string { return "usage" }
+
+var commands = map[string]func(args []string) error{
+	"repl":    replCmd,
+	"equiv":   equivCmd,
+	"subset":  subsetCmd,
+	"empty":   emptyCmd,
+	"witness": witnessCmd,
+	"count":   countCmd,
+	"dot":     graphCmd("dot", writeDOT),
+	"mermaid": graphCmd("mermaid", writeMermaid),
+	"table":   graphCmd("table", writeTable),
+}
+
+func main() {
+	args := os.Args[1:]
+	name := "repl"
+	if len(args) != 0 {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch err := cmd(args).(type) {
+	case nil:
+	case errFailed:
+		os.Exit(1)
+	case errUsage:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "dr: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// parsePatterns parses exactly n patterns.
+func parsePatterns(args []string, n int) ([]dr.Regex, error) {
+	if len(args) != n {
+		return nil, errUsage{}
+	}
+
+	rs := make([]dr.Regex, n)
+	for i, a := range args {
+		r, err := dr.Parse(a)
+		if err != nil {
+			return nil, err
+		}
+		rs[i] = r
+	}
+	return rs, nil
+}
+
+func replCmd(args []string) error {
+	if len(args) > 1 {
+		return errUsage{}
+	}
+	pattern := ""
+	if len(args) == 1 {
+		pattern = args[0]
+	}
+	return repl(os.Stdin, os.Stdout, pattern)
+}
+
+func equivCmd(args []string) error {
+	rs, err := parsePatterns(args, 2)
+	if err != nil {
+		return err
+	}
+
+	w, ok := dr.Equivalent(rs[0], rs[1])
+	if ok {
+		fmt.Println("equivalent")
+		return nil
+	}
+
+	if dr.Match(rs[0], w) {
+		fmt.Printf("not equivalent: %q matches %v but not %v\n", w, args[0], args[1])
+	} else {
+		fmt.Printf("not equivalent: %q matches %v but not %v\n", w, args[1], args[0])
+	}
+	return errFailed{}
+}
+
+func subsetCmd(args []string) error {
+	rs, err := parsePatterns(args, 2)
+	if err != nil {
+		return err
+	}
+
+	w, ok := dr.Subset(rs[0], rs[1])
+	if ok {
+		fmt.Println("subset")
+		return nil
+	}
+
+	fmt.Printf("not a subset: %q matches %v but not %v\n", w, args[0], args[1])
+	return errFailed{}
+}
+
+func emptyCmd(args []string) error {
+	rs, err := parsePatterns(args, 1)
+	if err != nil {
+		return err
+	}
+
+	w, ok := dr.Witness(rs[0])
+	if !ok {
+		fmt.Println("empty")
+		return nil
+	}
+
+	fmt.Printf("not empty: %q matches\n", w)
+	return errFailed{}
+}
+
+func witnessCmd(args []string) error {
+	rs, err := parsePatterns(args, 1)
+	if err != nil {
+		return err
+	}
+
+	w, ok := dr.Witness(rs[0])
+	if !ok {
+		fmt.Println("matches nothing")
+		return errFailed{}
+	}
+
+	fmt.Printf("%q\n", w)
+	return nil
+}
+
+func countCmd(args []string) error {
+	fs := flag.NewFlagSet("count", flag.ContinueOnError)
+	n := fs.Int("n", 5, "longest length to count")
+	maxStates := fs.Int("max", 10000, "maximum number of DFA states (0 for no limit)")
+	fs.Usage = func() {}
+
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return errUsage{}
+	}
+	rs, err := parsePatterns(args, 1)
+	if err != nil {
+		return err
+	}
+
+	d, err := dr.Compile(rs[0], *maxStates)
+	if err != nil {
+		return err
+	}
+
+	for length := 0; length <= *n; length++ {
+		fmt.Printf("%v\t%v\n", length, d.Count(length))
+	}
+	return nil
+}
+
+// parseInterspersed parses flags which may come before or after the
+// other arguments, as in "dr count A -n 5", returning the other arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+}
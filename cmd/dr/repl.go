@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jakebailey/dr"
+)
+
+const replHelp = `commands:
+  :reset      go back to the original pattern
+  :back       undo the last derivative
+  :witness    show a shortest string matched by the current derivative
+  :pattern p  start again with a new pattern
+  :help       list the commands
+  :quit       exit
+anything else is taken one character at a time`
+
+// liveMaxStates bounds the derivatives explored after each step to tell
+// whether the current derivative can still match. Finding a witness may
+// take far longer, so it waits for :witness.
+const liveMaxStates = 1000
+
+// session is the state of the REPL: the derivatives taken so far, starting
+// with the parsed pattern, and the characters they were taken with.
+type session struct {
+	w       io.Writer
+	history []dr.Regex
+	input   []rune
+}
+
+func repl(in io.Reader, w io.Writer, pattern string) error {
+	s := &session{w: w}
+	if pattern != "" {
+		if err := s.start(pattern); err != nil {
+			return err
+		}
+	}
+
+	prompt := func() {
+		if len(s.history) == 0 {
+			fmt.Fprint(w, "pattern> ")
+		} else {
+			fmt.Fprint(w, "> ")
+		}
+	}
+
+	sc := bufio.NewScanner(in)
+	for prompt(); sc.Scan(); prompt() {
+		if !s.line(sc.Text()) {
+			return nil
+		}
+	}
+	fmt.Fprintln(w)
+	return sc.Err()
+}
+
+// line handles a line of input, returning false if the REPL should exit.
+func (s *session) line(line string) bool {
+	if len(s.history) == 0 && !strings.HasPrefix(line, ":") {
+		if err := s.start(line); err != nil {
+			fmt.Fprintln(s.w, err)
+		}
+		return true
+	}
+
+	if !strings.HasPrefix(line, ":") {
+		for _, c := range line {
+			s.derive(c)
+		}
+		return true
+	}
+
+	cmd, arg := line, ""
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch cmd {
+	case ":reset":
+		if len(s.history) != 0 {
+			s.history, s.input = s.history[:1], s.input[:0]
+			s.show()
+		}
+	case ":back":
+		if len(s.history) <= 1 {
+			fmt.Fprintln(s.w, "nothing to undo")
+			break
+		}
+		s.history, s.input = s.history[:len(s.history)-1], s.input[:len(s.input)-1]
+		s.show()
+	case ":witness":
+		if len(s.history) == 0 {
+			fmt.Fprintln(s.w, "no pattern")
+			break
+		}
+		if w, ok := dr.Witness(s.current()); ok {
+			fmt.Fprintf(s.w, "%q\n", w)
+		} else {
+			fmt.Fprintln(s.w, "matches nothing")
+		}
+	case ":pattern":
+		if err := s.start(arg); err != nil {
+			fmt.Fprintln(s.w, err)
+		}
+	case ":help":
+		fmt.Fprintln(s.w, replHelp)
+	case ":quit":
+		return false
+	default:
+		fmt.Fprintf(s.w, "unknown command %v, try :help\n", cmd)
+	}
+	return true
+}
+
+func (s *session) start(pattern string) error {
+	r, err := dr.Parse(pattern)
+	if err != nil {
+		return err
+	}
+	s.history, s.input = []dr.Regex{dr.Derive(r, "")}, nil
+	s.show()
+	return nil
+}
+
+func (s *session) current() dr.Regex {
+	return s.history[len(s.history)-1]
+}
+
+func (s *session) derive(c rune) {
+	s.history = append(s.history, dr.Derive(s.current(), string(c)))
+	s.input = append(s.input, c)
+	s.show()
+}
+
+// show prints the current derivative and its properties.
+func (s *session) show() {
+	r := s.current()
+
+	if len(s.input) == 0 {
+		fmt.Fprintf(s.w, "%v\n", r)
+	} else {
+		fmt.Fprintf(s.w, "%q => %v\n", string(s.input), r)
+	}
+	fmt.Fprintf(s.w, "  accepting: %v, dead: %v, size: %v\n", r.Accepting(), dead(r), dr.Size(r))
+}
+
+// dead reports whether r matches nothing, exploring at most liveMaxStates
+// of its derivatives, or "unknown" if that isn't enough to tell.
+func dead(r dr.Regex) string {
+	d, complete := dr.Explore(r, dr.ExploreOptions{MaxStates: liveMaxStates})
+	for i := 0; i < d.NumStates(); i++ {
+		if d.Accepting(i) {
+			return "false"
+		}
+	}
+	if !complete {
+		return "unknown"
+	}
+	return "true"
+}
@@ -0,0 +1,81 @@
+// Command drgen generates a Go function which matches a dr regex.
+//
+// Usage:
+//
+//	drgen [flags] pattern
+//
+// The generated function has the signature func(s string) bool, and is
+// written as a switch-based DFA with no dependencies. With -binary, the
+// DFA is instead written in the format read by (*dr.DFA).UnmarshalBinary,
+// suitable for embedding.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jakebailey/dr"
+)
+
+var (
+	pkg       = flag.String("pkg", "main", "package name of the generated file")
+	fn        = flag.String("func", "Match", "name of the generated function")
+	out       = flag.String("o", "", "output file (default stdout)")
+	maxStates = flag.Int("max", 10000, "maximum number of DFA states (0 for no limit)")
+	binary    = flag.Bool("binary", false, "write the DFA in binary instead of Go source")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: drgen [flags] pattern\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "drgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern string) error {
+	r, err := dr.Parse(pattern)
+	if err != nil {
+		return err
+	}
+
+	d, err := dr.Compile(r, *maxStates)
+	if err != nil {
+		return err
+	}
+
+	var src []byte
+	if *binary {
+		src, err = d.MarshalBinary()
+	} else {
+		var buf bytes.Buffer
+		err = dr.WriteGo(&buf, d, dr.GoOptions{
+			Package:   *pkg,
+			Func:      *fn,
+			Generator: "drgen",
+		})
+		src = buf.Bytes()
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(*out, src, 0644)
+}
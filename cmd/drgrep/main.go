@@ -0,0 +1,235 @@
+// Command drgrep prints lines matching a dr regex.
+//
+// Usage:
+//
+//	drgrep [flags] pattern [file ...]
+//
+// With no files, drgrep reads standard input. A line is selected if any
+// substring of it matches the pattern, or with -x, if the whole line does.
+// Complement (!) and intersection (&) make patterns grep can't express,
+// such as lines mentioning an error but not a timeout:
+//
+//	drgrep -x '.*error.*&!(.*timeout.*)' app.log
+//
+// In substring mode a complement is rarely what's wanted, since it
+// matches some substring of nearly every line.
+//
+// The exit status is 0 if a line was selected, 1 if none were, and 2 if
+// an error occurred.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jakebailey/dr"
+)
+
+var (
+	invert    = flag.Bool("v", false, "select lines that don't match")
+	count     = flag.Bool("c", false, "print only a count of selected lines")
+	number    = flag.Bool("n", false, "print line numbers")
+	whole     = flag.Bool("x", false, "match whole lines only")
+	colorMode = flag.String("color", "auto", "colorize output: auto, always or never")
+	maxStates = flag.Int("max", 10000, "maximum number of DFA states before matching without one")
+)
+
+// The colors used by GNU grep.
+const (
+	colorMatch = "\x1b[01;31m"
+	colorFile  = "\x1b[35m"
+	colorLine  = "\x1b[32m"
+	colorSep   = "\x1b[36m"
+	colorReset = "\x1b[m"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: drgrep [flags] pattern [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	color, err := useColor(*colorMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "drgrep: %v\n", err)
+		os.Exit(2)
+	}
+
+	g, err := newGrep(flag.Arg(0), color)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "drgrep: %v\n", err)
+		os.Exit(2)
+	}
+
+	files := flag.Args()[1:]
+	g.names = len(files) > 1
+
+	status := 1
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		found, err := g.file(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "drgrep: %v\n", err)
+			status = 2
+			continue
+		}
+		if found && status == 1 {
+			status = 0
+		}
+	}
+
+	if err := g.w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "drgrep: %v\n", err)
+		status = 2
+	}
+	os.Exit(status)
+}
+
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		fi, err := os.Stdout.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("invalid -color %q", mode)
+	}
+}
+
+type grep struct {
+	r     dr.Regex
+	match func(string) bool
+	color bool
+	names bool
+	w     *bufio.Writer
+}
+
+func newGrep(pattern string, color bool) (*grep, error) {
+	r, err := dr.Parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selecting lines is done by matching the whole line, so substring
+	// mode matches .*r.* instead.
+	m := r
+	if !*whole {
+		anything := dr.NewKleene(dr.NewAny())
+		m = dr.NewConcat(anything, dr.NewConcat(r, anything))
+	}
+
+	g := &grep{
+		r:     r,
+		color: color,
+		w:     bufio.NewWriter(os.Stdout),
+	}
+
+	if d, err := dr.Compile(m, *maxStates); err == nil {
+		g.match = d.Match
+	} else {
+		g.match = func(s string) bool { return dr.Match(m, s) }
+	}
+	return g, nil
+}
+
+// file greps the named file, or standard input for "-", returning true if
+// any line was selected.
+func (g *grep) file(name string) (bool, error) {
+	var in io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		in = f
+	} else {
+		name = "(standard input)"
+	}
+
+	br := bufio.NewReader(in)
+	selected := 0
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return selected != 0, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if g.match(line) == *invert {
+			continue
+		}
+		selected++
+		if !*count {
+			g.print(name, n, line)
+		}
+	}
+
+	if *count {
+		if g.names {
+			g.prefix(name, colorFile)
+		}
+		fmt.Fprintln(g.w, selected)
+	}
+	return selected != 0, nil
+}
+
+func (g *grep) print(name string, n int, line string) {
+	if g.names {
+		g.prefix(name, colorFile)
+	}
+	if *number {
+		g.prefix(fmt.Sprint(n), colorLine)
+	}
+
+	if !g.color || *invert {
+		fmt.Fprintln(g.w, line)
+		return
+	}
+
+	matches := [][]int{{0, len(line)}}
+	if !*whole {
+		matches = dr.FindAllIndex(g.r, line, -1)
+	}
+
+	last := 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue
+		}
+		g.w.WriteString(line[last:m[0]])
+		g.w.WriteString(colorMatch)
+		g.w.WriteString(line[m[0]:m[1]])
+		g.w.WriteString(colorReset)
+		last = m[1]
+	}
+	g.w.WriteString(line[last:])
+	g.w.WriteByte('\n')
+}
+
+// prefix writes a file name or line number followed by a separator.
+func (g *grep) prefix(s, color string) {
+	if g.color {
+		fmt.Fprintf(g.w, "%v%v%v%v:%v", color, s, colorReset, colorSep, colorReset)
+		return
+	}
+	fmt.Fprintf(g.w, "%v:", s)
+}
@@ -0,0 +1,66 @@
+package dr
+
+import "math/big"
+
+// Count returns the number of strings of exactly n characters matched by
+// the DFA. Surrogate halves can't appear in a string, so they aren't
+// counted as characters. The DFA must be complete.
+func (d *DFA) Count(n int) *big.Int {
+	if !d.complete() {
+		panic(ErrIncomplete)
+	}
+
+	size := d.alpha.size()
+	widths := make([]*big.Int, size)
+	for class := range widths {
+		lo, hi := d.alpha.bounds(class)
+		widths[class] = big.NewInt(int64(hi-lo) + 1 - overlap(lo, hi, 0xD800, 0xDFFF))
+	}
+
+	ways := make([]*big.Int, len(d.accept))
+	for i := range ways {
+		ways[i] = new(big.Int)
+	}
+	ways[0].SetInt64(1)
+
+	var t big.Int
+	for ; n > 0; n-- {
+		next := make([]*big.Int, len(ways))
+		for i := range next {
+			next[i] = new(big.Int)
+		}
+
+		for i, w := range ways {
+			if w.Sign() == 0 {
+				continue
+			}
+			for class, width := range widths {
+				j := d.trans[i*size+class]
+				next[j].Add(next[j], t.Mul(w, width))
+			}
+		}
+		ways = next
+	}
+
+	total := new(big.Int)
+	for i, w := range ways {
+		if d.accept[i] {
+			total.Add(total, w)
+		}
+	}
+	return total
+}
+
+// overlap returns the number of runes in both lo-hi and a-b.
+func overlap(lo, hi, a, b rune) int64 {
+	if a > lo {
+		lo = a
+	}
+	if b < hi {
+		hi = b
+	}
+	if lo > hi {
+		return 0
+	}
+	return int64(hi-lo) + 1
+}
@@ -0,0 +1,155 @@
+package dr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// definition is a line of input to ParseDefs.
+type definition struct {
+	line  int    // line number, starting at 1
+	text  string // the whole line
+	start int    // byte offset of the pattern in text
+	end   int    // byte offset of the end of the pattern in text
+
+	regex   Regex // the parsed pattern, once known
+	parsing bool  // whether the pattern is being parsed
+}
+
+// defsParser resolves definitions on demand, so they may refer to
+// definitions later in the input.
+type defsParser struct {
+	defs  map[string]*definition
+	stack []string // the definitions being parsed, innermost last
+	err   *SyntaxError
+}
+
+// ParseDefs parses a list of named definitions, one per line:
+//
+//	# comments and blank lines are ignored
+//	ident = [a-z][a-z0-9]*
+//	path = {ident}(/{ident})*
+//
+// A definition may refer to any other with {name}, before or after it,
+// as long as no definition refers back to itself. Names are made of
+// ASCII letters, digits and underscores, and don't start with a digit.
+// Whitespace around the pattern is ignored, so a trailing space must be
+// written as \x20.
+//
+// If the input is malformed, the returned error is a *SyntaxError for
+// the offending line, with Line counting from the start of the input.
+func ParseDefs(r io.Reader) (map[string]Regex, error) {
+	p := &defsParser{defs: make(map[string]*definition)}
+
+	var names []string
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+
+		nameStart := strings.Index(text, trimmed)
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			return nil, lineError(text, line, len(text), "missing '='")
+		}
+
+		name := strings.TrimSpace(text[:eq])
+		if !isName(name) {
+			return nil, lineError(text, line, nameStart, fmt.Sprintf("invalid name %q", name))
+		}
+		if d, ok := p.defs[name]; ok {
+			return nil, lineError(text, line, nameStart, fmt.Sprintf("%v is already defined on line %v", name, d.line))
+		}
+
+		pattern := strings.TrimSpace(text[eq+1:])
+		start := eq + 1 + strings.Index(text[eq+1:], pattern)
+		p.defs[name] = &definition{
+			line:  line,
+			text:  text,
+			start: start,
+			end:   start + len(pattern),
+		}
+		names = append(names, name)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	regexes := make(map[string]Regex, len(names))
+	for _, name := range names {
+		r, err := p.resolve(name)
+		if err != nil {
+			return nil, p.err
+		}
+		regexes[name] = r
+	}
+	return regexes, nil
+}
+
+// resolve returns the regex for a name, parsing its definition if it
+// hasn't been already. If the definition is malformed, the first error
+// found is kept in p.err.
+func (p *defsParser) resolve(name string) (Regex, *classError) {
+	d, ok := p.defs[name]
+	if !ok {
+		return nil, &classError{0, fmt.Sprintf("undefined name %q", name)}
+	}
+	if d.regex != nil {
+		return d.regex, nil
+	}
+
+	if d.parsing {
+		var cycle []string
+		for i, n := range p.stack {
+			if n == name {
+				cycle = append(cycle, p.stack[i:]...)
+				break
+			}
+		}
+		cycle = append(cycle, name)
+		return nil, &classError{0, "cycle in definitions: " + strings.Join(cycle, " -> ")}
+	}
+
+	d.parsing = true
+	p.stack = append(p.stack, name)
+	r, err := parse(d.text[d.start:d.end], Options{}, p.resolve)
+	p.stack = p.stack[:len(p.stack)-1]
+	d.parsing = false
+
+	if err != nil {
+		if p.err == nil {
+			se := err.(*SyntaxError)
+			p.err = lineError(d.text, d.line, d.start+se.Offset, se.Problem, se.Expected...)
+		}
+		return nil, &classError{0, fmt.Sprintf("invalid definition of %v", name)}
+	}
+
+	d.regex = r
+	return r, nil
+}
+
+// lineError returns a *SyntaxError at a byte offset in line n of the
+// input to ParseDefs.
+func lineError(text string, n, offset int, problem string, expected ...string) *SyntaxError {
+	e := newSyntaxError(text, offset, problem, expected...)
+	e.Line = n
+	return e
+}
+
+// isName returns true if s is a valid name for a definition.
+func isName(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package dr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDefs(t *testing.T) {
+	defs, err := ParseDefs(strings.NewReader(`# paths
+path = {ident}(/{ident})*
+ident = [a-z][a-z0-9]*
+
+private = {path}&!(.*/internal(/.*)*)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(defs) != 3 {
+		t.Errorf("got %v definitions, want 3", len(defs))
+	}
+
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"ident", "a1", true},
+		{"ident", "1a", false},
+		{"path", "usr/local/bin", true},
+		{"path", "usr//bin", false},
+		{"private", "usr/local", true},
+		{"private", "usr/internal", false},
+		{"private", "usr/internal/x", false},
+		{"private", "usr/internals", true},
+	}
+
+	for _, test := range tests {
+		if got := Match(defs[test.name], test.s); got != test.want {
+			t.Errorf("%v matches %q = %v, want %v", test.name, test.s, got, test.want)
+		}
+	}
+}
+
+func TestParseDefsErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		line    int
+		column  int
+		problem string
+	}{
+		{"a = b\nc", 2, 2, "missing '='"},
+		{"1a = b", 1, 1, `invalid name "1a"`},
+		{"a = b\n  a = c", 2, 3, "a is already defined on line 1"},
+		{"a = b{c}", 1, 6, `undefined name "c"`},
+		{"a = b*(", 1, 8, "unexpected end of pattern"},
+		{"a = {a}*", 1, 5, "cycle in definitions: a -> a"},
+		{"a = x{b}\nb = y{c}\nc = !{a}", 3, 6, "cycle in definitions: a -> b -> c -> a"},
+		{"a = {b}\nb = [z-a]", 2, 6, "invalid range 'z-a'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseDefs(strings.NewReader(test.input))
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseDefs(%q) returned %v, want a *SyntaxError", test.input, err)
+			continue
+		}
+		if se.Line != test.line || se.Column != test.column || se.Problem != test.problem {
+			t.Errorf("ParseDefs(%q) error at %v:%v %q, want %v:%v %q",
+				test.input, se.Line, se.Column, se.Problem, test.line, test.column, test.problem)
+		}
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	digits := MustParse(`\d\d*`)
+	r, err := ParseWithOptions(`v{digits}(\.{digits})*`, Options{Defs: map[string]Regex{"digits": digits}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Match(r, "v1.20.3") || Match(r, "v1..2") {
+		t.Errorf("%v matches the wrong strings", r)
+	}
+
+	if _, err := Parse("{x}"); err == nil {
+		t.Error("Parse accepted an undefined reference")
+	}
+
+	literals := []struct {
+		pattern, s string
+	}{
+		{"{", "{"},
+		{"a{1}", "a{1}"},
+		{"{}", "{}"},
+		{`\{x}`, "{x}"},
+	}
+	for _, test := range literals {
+		r := MustParse(test.pattern)
+		if !Match(r, test.s) {
+			t.Errorf("%q does not match %q", test.pattern, test.s)
+		}
+		if got, want := r.String(), strings.Replace(test.s, "{", `\{`, -1); got != want {
+			t.Errorf("MustParse(%q) = %v, want %v", test.pattern, got, want)
+		}
+	}
+}
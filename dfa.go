@@ -0,0 +1,268 @@
+package dr
+
+import "errors"
+
+// ErrStateLimit is returned when compiling a regex would exceed
+// the requested number of states.
+var ErrStateLimit = errors.New("state limit exceeded")
+
+// ErrIncomplete is returned when encoding a DFA from Explore which
+// stopped at its state limit, since some of its states have no
+// transitions.
+var ErrIncomplete = errors.New("DFA is incomplete")
+
+// DFA is a deterministic automaton whose states are the derivatives of a
+// regex. Runes are grouped into classes which always have the same
+// derivative, so each state has one transition per class. Matching
+// doesn't modify a DFA, so it is safe for concurrent use.
+type DFA struct {
+	alpha  alphabet
+	terms  []Regex
+	accept []bool
+	trans  []int
+}
+
+// Compile builds the DFA for a regex by exploring all of its derivatives.
+// Brzozowski showed that there are only finitely many of them (up to
+// similarity), but there may be exponentially many; if maxStates is
+// positive and the DFA would need more states, ErrStateLimit is returned.
+func Compile(r Regex, maxStates int) (*DFA, error) {
+	d, complete := Explore(r, ExploreOptions{MaxStates: maxStates})
+	if !complete {
+		return nil, ErrStateLimit
+	}
+	return d, nil
+}
+
+// ExploreOptions configures Explore.
+type ExploreOptions struct {
+	// MaxStates limits the number of states whose derivatives are taken,
+	// if it is positive.
+	MaxStates int
+
+	// Alphabet, if not empty, restricts the characters whose derivatives
+	// are taken. Every other character leads to the state for ∅, so the
+	// DFA only matches strings made of the alphabet.
+	Alphabet string
+}
+
+// Explore builds the DFA for r breadth first, as Compile does, for tools
+// which show or analyze the derivatives. If exploration stops at the
+// state limit, complete is false, and the states discovered but not
+// expanded have no transitions; see Expanded. Such a DFA can be
+// inspected and matched, falling back to derivatives past the expanded
+// states, but not encoded.
+func Explore(r Regex, opts ExploreOptions) (d *DFA, complete bool) {
+	var allowed map[rune]bool
+	alphaRegexes := []Regex{r}
+	if opts.Alphabet != "" {
+		allowed = make(map[rune]bool)
+		for _, c := range opts.Alphabet {
+			allowed[c] = true
+			alphaRegexes = append(alphaRegexes, NewChar(c))
+		}
+	}
+
+	d = &DFA{
+		alpha: newAlphabet(alphaRegexes...),
+	}
+	index := make(map[string]int)
+
+	add := func(r Regex) int {
+		k := termKey(r)
+		if i, ok := index[k]; ok {
+			return i
+		}
+
+		i := len(d.terms)
+		index[k] = i
+		d.terms = append(d.terms, r)
+		d.accept = append(d.accept, r.Accepting())
+		return i
+	}
+
+	add(simplify(locate(r)))
+
+	n := d.alpha.size()
+	for i := 0; i < len(d.terms); i++ {
+		if opts.MaxStates > 0 && i >= opts.MaxStates {
+			return d, false
+		}
+
+		for class := 0; class < n; class++ {
+			c := d.alpha.rep(class)
+			next := NewEmpty()
+			if allowed == nil || allowed[c] {
+				next = simplify(d.terms[i].Derivative(c))
+			}
+			d.trans = append(d.trans, add(next))
+		}
+	}
+
+	return d, true
+}
+
+// MustCompile is like Compile, but panics on error.
+func MustCompile(r Regex, maxStates int) *DFA {
+	d, err := Compile(r, maxStates)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NumStates returns the number of states in the DFA. State 0 is
+// the start state.
+func (d *DFA) NumStates() int {
+	return len(d.accept)
+}
+
+// Next returns the state reached from state i on c. State i must be
+// expanded.
+func (d *DFA) Next(i int, c rune) int {
+	return d.trans[i*d.alpha.size()+d.alpha.class(c)]
+}
+
+// State returns the derivative of the regex which state i represents,
+// or nil if the DFA was decoded by UnmarshalBinary.
+func (d *DFA) State(i int) Regex {
+	if d.terms == nil {
+		return nil
+	}
+	return d.terms[i]
+}
+
+// Expanded returns true if the transitions of state i are known, which is
+// always the case unless Explore stopped early.
+func (d *DFA) Expanded(i int) bool {
+	return i < d.expanded()
+}
+
+// Transition is a set of characters on which a state moves to state To.
+type Transition struct {
+	To     int
+	Ranges [][2]rune
+}
+
+// String describes the characters of the transition in class syntax, for
+// example [a-c] or [^\n].
+func (t Transition) String() string {
+	return rangesLabel(t.Ranges)
+}
+
+// Transitions returns the transitions of state i, grouped by target
+// state, in order of their smallest character.
+func (d *DFA) Transitions(i int) []Transition {
+	if !d.Expanded(i) {
+		return nil
+	}
+
+	edges := d.edges(i)
+	ts := make([]Transition, len(edges))
+	for j, e := range edges {
+		ts[j] = Transition{To: e.to, Ranges: e.ranges}
+	}
+	return ts
+}
+
+// Accepting returns true if state i is accepting.
+func (d *DFA) Accepting(i int) bool {
+	return d.accept[i]
+}
+
+// Dead returns true if state i is the state for ∅, which matches
+// nothing. Drawings of the DFA hide it by default. For a DFA decoded by
+// UnmarshalBinary, this is only known for expanded states.
+func (d *DFA) Dead(i int) bool {
+	if d.terms != nil {
+		return isEmpty(d.terms[i])
+	}
+	return d.Expanded(i) && d.dead(i)
+}
+
+// Match returns true if the string matches the DFA. If the DFA is
+// incomplete, matching continues with the derivatives of the first state
+// which wasn't expanded.
+func (d *DFA) Match(s string) bool {
+	i := 0
+	for j, c := range s {
+		if !d.Expanded(i) {
+			return Match(d.terms[i], s[j:])
+		}
+		i = d.Next(i, c)
+	}
+	return d.accept[i]
+}
+
+// dead returns true if state i only ever transitions to itself and
+// is not accepting.
+func (d *DFA) dead(i int) bool {
+	return !d.accept[i] && d.loops(i)
+}
+
+// full returns true if state i only ever transitions to itself and
+// is accepting.
+func (d *DFA) full(i int) bool {
+	return d.accept[i] && d.loops(i)
+}
+
+// complete returns true if every state has transitions.
+func (d *DFA) complete() bool {
+	return d.expanded() == d.NumStates()
+}
+
+// expanded returns the number of states which have transitions.
+func (d *DFA) expanded() int {
+	return len(d.trans) / d.alpha.size()
+}
+
+// edge is the set of rune ranges which lead to a state.
+type edge struct {
+	to     int
+	ranges [][2]rune
+	width  int64
+}
+
+// edges returns the transitions of state i, grouped by target state,
+// in order of their smallest rune.
+func (d *DFA) edges(i int) []edge {
+	n := d.alpha.size()
+	trans := d.trans[i*n : (i+1)*n]
+
+	var edges []edge
+	index := make(map[int]int)
+
+	for class := 0; class < n; class++ {
+		lo, hi := d.alpha.bounds(class)
+		if lo > hi {
+			continue
+		}
+
+		j, ok := index[trans[class]]
+		if !ok {
+			j = len(edges)
+			index[trans[class]] = j
+			edges = append(edges, edge{to: trans[class]})
+		}
+
+		e := &edges[j]
+		if len(e.ranges) != 0 && e.ranges[len(e.ranges)-1][1]+1 == lo {
+			e.ranges[len(e.ranges)-1][1] = hi
+		} else {
+			e.ranges = append(e.ranges, [2]rune{lo, hi})
+		}
+		e.width += int64(hi-lo) + 1
+	}
+
+	return edges
+}
+
+func (d *DFA) loops(i int) bool {
+	n := d.alpha.size()
+	for _, j := range d.trans[i*n : (i+1)*n] {
+		if j != i {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package dr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// DefaultDOTMaxStates is the number of states explored by WriteDOT
+// if DOTOptions.MaxStates is zero.
+const DefaultDOTMaxStates = 100
+
+// DOTOptions configures WriteDOT.
+type DOTOptions struct {
+	// MaxStates limits the number of states whose derivatives are taken.
+	// States beyond the limit are drawn dashed, without edges.
+	MaxStates int
+
+	// ShowDead includes the state which accepts nothing, and the edges
+	// leading to it. It is hidden by default.
+	ShowDead bool
+
+	// Alphabet restricts the characters followed, as in ExploreOptions.
+	Alphabet string
+}
+
+// WriteDOT writes the automaton formed by the derivatives of r as a
+// Graphviz DOT graph. Nodes are labeled with their derivative, edges with
+// the runes leading between them, and accepting states are drawn with
+// double circles.
+func WriteDOT(w io.Writer, r Regex, opts DOTOptions) error {
+	maxStates := opts.MaxStates
+	if maxStates == 0 {
+		maxStates = DefaultDOTMaxStates
+	}
+
+	d, _ := Explore(r, ExploreOptions{
+		MaxStates: maxStates,
+		Alphabet:  opts.Alphabet,
+	})
+	return d.WriteDOT(w, opts.ShowDead)
+}
+
+// WriteDOT writes the DFA as a Graphviz DOT graph, as the WriteDOT
+// function does. If the DFA is from an Explore that stopped early, the
+// states which weren't expanded are drawn dashed. If the DFA has no
+// derivatives, as after UnmarshalBinary, states are labeled by number.
+func (d *DFA) WriteDOT(w io.Writer, showDead bool) error {
+	expanded := d.expanded()
+
+	hidden := func(i int) bool {
+		return !showDead && d.Dead(i)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph dr {\n")
+	buf.WriteString("\trankdir=LR;\n")
+	if expanded < d.NumStates() {
+		fmt.Fprintf(&buf, "\tlabel=%v;\n", dotQuote(fmt.Sprintf("stopped after %v states", expanded)))
+	}
+	buf.WriteString("\tstart [shape=point];\n")
+
+	for i := range d.accept {
+		if hidden(i) {
+			continue
+		}
+
+		shape := "circle"
+		if d.accept[i] {
+			shape = "doublecircle"
+		}
+		style := ""
+		if i >= expanded {
+			style = " style=dashed"
+		}
+
+		label := fmt.Sprint(i)
+		if d.terms != nil {
+			label = d.terms[i].String()
+		}
+
+		fmt.Fprintf(&buf, "\t%v [shape=%v%v label=%v];\n", i, shape, style, dotQuote(label))
+	}
+
+	buf.WriteString("\tstart -> 0;\n")
+
+	for i := 0; i < expanded; i++ {
+		if hidden(i) {
+			continue
+		}
+
+		for _, e := range d.edges(i) {
+			if hidden(e.to) {
+				continue
+			}
+			fmt.Fprintf(&buf, "\t%v -> %v [label=%v];\n", i, e.to, dotQuote(rangesLabel(e.ranges)))
+		}
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// rangesLabel describes a set of rune ranges in character class syntax.
+// Sets covering more than half of Unicode are written as negated classes.
+func rangesLabel(ranges [][2]rune) string {
+	var width int64
+	for _, r := range ranges {
+		width += int64(r[1]-r[0]) + 1
+	}
+
+	if width == unicode.MaxRune+1 {
+		return "."
+	}
+
+	neg := width > (unicode.MaxRune+1)/2
+	if neg {
+		ranges = invertRanges(ranges)
+	}
+
+	if !neg && len(ranges) == 1 && ranges[0][0] == ranges[0][1] {
+		return runeLabel(ranges[0][0])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	if neg {
+		buf.WriteString("^")
+	}
+	for _, r := range ranges {
+		buf.WriteString(runeLabel(r[0]))
+		if r[1] > r[0] {
+			if r[1] > r[0]+1 {
+				buf.WriteString("-")
+			}
+			buf.WriteString(runeLabel(r[1]))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+func runeLabel(r rune) string {
+	if strings.ContainsRune(`[]^-\`, r) {
+		return `\` + string(r)
+	}
+	return escapeRune(r)
+}
+
+// dotQuote quotes s as a DOT string.
+func dotQuote(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,88 @@
+package dr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, MustParse("a!(b)"), DOTOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph dr {
+	rankdir=LR;
+	start [shape=point];
+	0 [shape=circle label="a!b"];
+	2 [shape=doublecircle label="!b"];
+	3 [shape=doublecircle label="!∅"];
+	4 [shape=circle label="!ε"];
+	start -> 0;
+	0 -> 2 [label="a"];
+	2 -> 3 [label="[^b]"];
+	2 -> 4 [label="b"];
+	3 -> 3 [label="."];
+	4 -> 3 [label="."];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestWriteDOTLimit(t *testing.T) {
+	var buf bytes.Buffer
+	opts := DOTOptions{MaxStates: 2, ShowDead: true}
+	if err := WriteDOT(&buf, MustParse("abcd"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, s := range []string{
+		`label="stopped after 2 states"`,
+		`1 [shape=circle label="∅"]`,
+		`2 [shape=circle style=dashed label="bcd"]`,
+		`0 -> 1 [label="[^a]"]`,
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("output does not contain %q:\n%v", s, got)
+		}
+	}
+}
+
+func TestDFAWriteDOT(t *testing.T) {
+	d, err := Compile(MustParse("ab"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded DFA
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := loaded.WriteDOT(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph dr {
+	rankdir=LR;
+	start [shape=point];
+	0 [shape=circle label="0"];
+	2 [shape=circle label="2"];
+	3 [shape=doublecircle label="3"];
+	start -> 0;
+	0 -> 2 [label="a"];
+	2 -> 3 [label="b"];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+package dr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError describes a malformed pattern.
+type SyntaxError struct {
+	Pattern string
+
+	Offset int // byte offset of the error
+	Line   int // line number, starting at 1
+	Column int // column in runes, starting at 1
+
+	// Rune is the offending rune, or -1 if the pattern ended early.
+	Rune rune
+
+	// Problem is a short description of the error, like "dangling '*'".
+	Problem string
+
+	// Expected lists what would have been valid at Offset, if known.
+	Expected []string
+}
+
+// Error returns a description of the error, followed by the offending
+// line of the pattern and a caret pointing at the error.
+func (e *SyntaxError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "syntax error at line %v column %v: %v", e.Line, e.Column, e.Problem)
+	if len(e.Expected) != 0 {
+		fmt.Fprintf(&buf, ", expected %v", orList(e.Expected))
+	}
+
+	lineStart := strings.LastIndex(e.Pattern[:e.Offset], "\n") + 1
+	lineEnd := strings.IndexByte(e.Pattern[e.Offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(e.Pattern)
+	} else {
+		lineEnd += e.Offset
+	}
+
+	buf.WriteString("\n\t")
+	buf.WriteString(e.Pattern[lineStart:lineEnd])
+	buf.WriteString("\n\t")
+	for _, c := range e.Pattern[lineStart:e.Offset] {
+		if c == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteByte('^')
+
+	return buf.String()
+}
+
+func orList(ss []string) string {
+	switch len(ss) {
+	case 1:
+		return ss[0]
+	default:
+		return strings.Join(ss[:len(ss)-1], ", ") + " or " + ss[len(ss)-1]
+	}
+}
+
+func newSyntaxError(s string, offset int, problem string, expected ...string) *SyntaxError {
+	e := &SyntaxError{
+		Pattern:  s,
+		Offset:   offset,
+		Rune:     -1,
+		Problem:  problem,
+		Expected: expected,
+	}
+
+	e.Line, e.Column = position(s, offset)
+	if offset < len(s) {
+		e.Rune, _ = utf8.DecodeRuneInString(s[offset:])
+	}
+
+	return e
+}
+
+// position returns the line and column of a byte offset in s.
+func position(s string, offset int) (line, column int) {
+	line, column = 1, 1
+	for _, c := range s[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// bracketEnd returns the length of the bracket class at the start of s,
+// or -1 if it isn't closed.
+func bracketEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ']':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// expectedExpr is what may start an expression.
+var expectedExpr = []string{"character", "'.'", "'('", "'!'"}
+
+// diagnose finds the first syntax error in a pattern which failed to
+// parse. It follows the grammar in peg.peg closely enough to explain
+// the failure; the generated parser only reports how far it got.
+func diagnose(s string) *SyntaxError {
+	const (
+		start = iota // at the start of an alternative or group
+		bang         // after '!', which must be followed by a factor
+		atom         // after a factor, which may be starred
+		unary        // after a star or complement, which may not be
+	)
+
+	state := start
+	type group struct {
+		offset int
+		bang   bool
+	}
+	var groups []group
+
+	after := func() {
+		if state == bang {
+			state = unary
+		} else {
+			state = atom
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+
+		switch c {
+		case '*':
+			if state != atom {
+				return newSyntaxError(s, i, "dangling '*'")
+			}
+			state = unary
+
+		case '+', '&':
+			if state == start || state == bang {
+				return newSyntaxError(s, i, fmt.Sprintf("unexpected '%c'", c), expectedExpr...)
+			}
+			state = start
+
+		case '!':
+			if state == bang {
+				return newSyntaxError(s, i, "unexpected '!'", "character", "'.'", "'('")
+			}
+			state = bang
+
+		case '(':
+			groups = append(groups, group{offset: i, bang: state == bang})
+			state = start
+			if strings.HasPrefix(s[i+size:], "?=") || strings.HasPrefix(s[i+size:], "?!") {
+				size += 2
+			}
+
+		case ')':
+			if len(groups) == 0 {
+				return newSyntaxError(s, i, "unmatched ')'")
+			}
+			if state == start || state == bang {
+				return newSyntaxError(s, i, "unexpected ')'", expectedExpr...)
+			}
+
+			g := groups[len(groups)-1]
+			groups = groups[:len(groups)-1]
+			if g.bang {
+				state = unary
+			} else {
+				state = atom
+			}
+
+		case '[':
+			end := bracketEnd(s[i:])
+			if end < 0 {
+				return newSyntaxError(s, len(s), "unclosed '['", "']'")
+			}
+			size = end
+			after()
+
+		case '\\':
+			if i+size == len(s) {
+				return newSyntaxError(s, i+size, "unexpected end of pattern", "escaped character")
+			}
+
+			next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+			switch {
+			case escaped[next] || strings.ContainsRune("dswDSWbB", next):
+			case next == 'p' || next == 'P':
+				rest := s[i+size+nextSize:]
+				switch {
+				case rest == "":
+					return newSyntaxError(s, len(s), "unexpected end of pattern", "Unicode class name")
+				case rest[0] == '{':
+					end := strings.IndexByte(rest, '}')
+					if end < 0 {
+						return newSyntaxError(s, len(s), "unexpected end of pattern", "'}'")
+					}
+					nextSize += end + 1
+				default:
+					_, n := utf8.DecodeRuneInString(rest)
+					nextSize += n
+				}
+			default:
+				_, n, err := unescape(s[i:])
+				if err != nil {
+					return newSyntaxError(s, i+err.offset, err.problem)
+				}
+				nextSize = n - size
+			}
+			size += nextSize
+			after()
+
+		default:
+			after()
+		}
+
+		i += size
+	}
+
+	switch {
+	case state == start || state == bang:
+		return newSyntaxError(s, len(s), "unexpected end of pattern", expectedExpr...)
+	case len(groups) != 0:
+		line, column := position(s, groups[len(groups)-1].offset)
+		problem := fmt.Sprintf("unclosed '(' at line %v column %v", line, column)
+		return newSyntaxError(s, len(s), problem, "')'")
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package dr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		offset   int
+		line     int
+		column   int
+		r        rune
+		problem  string
+		expected []string
+	}{
+		{"", 0, 1, 1, -1, "unexpected end of pattern", expectedExpr},
+		{"*", 0, 1, 1, '*', "dangling '*'", nil},
+		{"a**", 2, 1, 3, '*', "dangling '*'", nil},
+		{"!a*", 2, 1, 3, '*', "dangling '*'", nil},
+		{"(a+*)", 3, 1, 4, '*', "dangling '*'", nil},
+		{"+a", 0, 1, 1, '+', "unexpected '+'", expectedExpr},
+		{"a++b", 2, 1, 3, '+', "unexpected '+'", expectedExpr},
+		{"a+&b", 2, 1, 3, '&', "unexpected '&'", expectedExpr},
+		{"a&", 2, 1, 3, -1, "unexpected end of pattern", expectedExpr},
+		{"a+", 2, 1, 3, -1, "unexpected end of pattern", expectedExpr},
+		{"!!a", 1, 1, 2, '!', "unexpected '!'", []string{"character", "'.'", "'('"}},
+		{"a!", 2, 1, 3, -1, "unexpected end of pattern", expectedExpr},
+		{"a)", 1, 1, 2, ')', "unmatched ')'", nil},
+		{"()", 1, 1, 2, ')', "unexpected ')'", expectedExpr},
+		{"(a", 2, 1, 3, -1, "unclosed '(' at line 1 column 1", []string{"')'"}},
+		{"a(b(c)", 6, 1, 7, -1, "unclosed '(' at line 1 column 2", []string{"')'"}},
+		{`\q`, 0, 1, 1, '\\', `invalid escape '\q'`, nil},
+		{`a\xZ1`, 1, 1, 2, '\\', `invalid escape '\xZ1'`, nil},
+		{`\u{12G}`, 0, 1, 1, '\\', `invalid escape '\u{12G}'`, nil},
+		{`\u{110000}`, 0, 1, 1, '\\', `escape '\u{110000}' is not a Unicode code point`, nil},
+		{`\u{D800}`, 0, 1, 1, '\\', `escape '\u{D800}' is a surrogate, not a character`, nil},
+		{`[a-\u{DFFF}]`, 3, 1, 4, '\\', `escape '\u{DFFF}' is a surrogate, not a character`, nil},
+		{`(\477)`, 1, 1, 2, '\\', `octal escape '\477' is greater than \377`, nil},
+		{`[\xG0]`, 1, 1, 2, '\\', `invalid escape '\xG0'`, nil},
+		{"(?=a", 4, 1, 5, -1, "unclosed '(' at line 1 column 1", []string{"')'"}},
+		{"(?!)", 3, 1, 4, ')', "unexpected ')'", expectedExpr},
+		{`ab\`, 3, 1, 4, -1, "unexpected end of pattern", []string{"escaped character"}},
+		{"äö**", 5, 1, 4, '*', "dangling '*'", nil},
+		{"ab\nc**", 5, 2, 3, '*', "dangling '*'", nil},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.pattern)
+		e, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Parse(%q) returned %v, want *SyntaxError", test.pattern, err)
+			continue
+		}
+
+		if e.Offset != test.offset || e.Line != test.line || e.Column != test.column {
+			t.Errorf("Parse(%q) error at offset %v line %v column %v, want %v, %v, %v",
+				test.pattern, e.Offset, e.Line, e.Column, test.offset, test.line, test.column)
+		}
+		if e.Rune != test.r {
+			t.Errorf("Parse(%q) error rune = %q, want %q", test.pattern, e.Rune, test.r)
+		}
+		if e.Problem != test.problem {
+			t.Errorf("Parse(%q) error problem = %q, want %q", test.pattern, e.Problem, test.problem)
+		}
+		if !reflect.DeepEqual(e.Expected, test.expected) {
+			t.Errorf("Parse(%q) error expected = %q, want %q", test.pattern, e.Expected, test.expected)
+		}
+	}
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	_, err := Parse("ab\n(cd")
+	want := "syntax error at line 2 column 4: unclosed '(' at line 2 column 1, expected ')'\n" +
+		"\t(cd\n" +
+		"\t   ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("got:\n%v\nwant:\n%v", err, want)
+	}
+
+	_, err = Parse("(a+)")
+	want = "syntax error at line 1 column 4: unexpected ')', expected character, '.', '(' or '!'\n" +
+		"\t(a+)\n" +
+		"\t   ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("got:\n%v\nwant:\n%v", err, want)
+	}
+}
+
+func TestDiagnoseValid(t *testing.T) {
+	for _, p := range []string{
+		"a",
+		"abc*+aad",
+		"!(a+b*(asd(!d)))+(def)*",
+		`\+\++\*(\!\\)`,
+		"(!a)*b",
+		".*!.",
+		`\n\t*\x41+\u{1F600}\101\0[\x00-\x1f]`,
+		`^a\b(?=b)(?!c)\B.$`,
+		"(?a)",
+	} {
+		if _, err := Parse(p); err != nil {
+			t.Errorf("Parse(%q) returned %v", p, err)
+		}
+		if e := diagnose(p); e != nil {
+			t.Errorf("diagnose(%q) returned %v", p, e)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package dr
+
+import "unicode"
+
+// The smallest and largest characters with case folding variants, as in
+// regexp/syntax. Ranges outside of these can be left alone.
+const (
+	minFold = 0x0041
+	maxFold = 0x1E943
+)
+
+// NewFold returns a case-insensitive version of r. Each character is
+// replaced by a class of its simple case folding equivalents, as given by
+// unicode.SimpleFold, and classes are extended with the equivalents of
+// their members. Negated classes exclude the equivalents too, so \W and
+// [^k] stay the complements of \w and [k] after folding.
+//
+// Characters without any other case are left as they are.
+func NewFold(r Regex) Regex {
+	return Rewrite(r, func(r Regex) Regex {
+		switch r := r.(type) {
+		case *char:
+			return foldChar(r)
+		case *class:
+			return foldClass(r)
+		default:
+			return r
+		}
+	})
+}
+
+func foldChar(c *char) Regex {
+	ranges := appendFolded(nil, c.r, c.r)
+	if len(ranges) == 1 && ranges[0][0] == ranges[0][1] {
+		return c
+	}
+	return NewClass(rangesTable(ranges), false)
+}
+
+func foldClass(c *class) Regex {
+	before := tableRanges(c.table)
+
+	var ranges [][2]rune
+	for _, r := range before {
+		ranges = appendFolded(ranges, r[0], r[1])
+	}
+	ranges = mergeRanges(ranges)
+
+	if equalRanges(ranges, before) {
+		return c
+	}
+	return NewClass(rangesTable(ranges), c.neg)
+}
+
+// appendFolded appends the range lo-hi and the case folding equivalents
+// of every character in it.
+func appendFolded(ranges [][2]rune, lo, hi rune) [][2]rune {
+	if lo <= minFold && hi >= maxFold || hi < minFold || lo > maxFold {
+		return append(ranges, [2]rune{lo, hi})
+	}
+	if lo < minFold {
+		ranges = append(ranges, [2]rune{lo, minFold - 1})
+		lo = minFold
+	}
+	if hi > maxFold {
+		ranges = append(ranges, [2]rune{maxFold + 1, hi})
+		hi = maxFold
+	}
+
+	ranges = append(ranges, [2]rune{lo, hi})
+	for c := lo; c <= hi; c++ {
+		for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+			ranges = append(ranges, [2]rune{f, f})
+		}
+	}
+	return ranges
+}
+
+func equalRanges(a, b [][2]rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package dr
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		fails   []string
+	}{
+		{"abc", []string{"abc", "ABC", "aBc"}, []string{"ab", "abd"}},
+		{"k", []string{"k", "K", "\u212a"}, []string{"x"}},
+		{"σ", []string{"σ", "Σ", "ς"}, []string{"s"}},
+		{"[a-c]*", []string{"", "aBC", "CAB"}, []string{"d", "D"}},
+		{"[^k]", []string{"x", "X"}, []string{"k", "K", "\u212a"}},
+		{`\p{Lu}`, []string{"A", "a"}, []string{"1"}},
+		{`\W`, []string{"-"}, []string{"a", "A"}},
+		{"1+.", []string{"1", "x", "X"}, []string{""}},
+		{"!(ab)", []string{"a", "abc"}, []string{"ab", "AB", "Ab"}},
+	}
+
+	for _, test := range tests {
+		r, err := ParseWithOptions(test.pattern, Options{CaseInsensitive: true})
+		if err != nil {
+			t.Errorf("ParseWithOptions(%q) returned %v", test.pattern, err)
+			continue
+		}
+		for _, s := range test.matches {
+			if !Match(r, s) {
+				t.Errorf("%q (folded to %v) does not match %q", test.pattern, r, s)
+			}
+		}
+		for _, s := range test.fails {
+			if Match(r, s) {
+				t.Errorf("%q (folded to %v) matches %q", test.pattern, r, s)
+			}
+		}
+	}
+}
+
+func TestFoldString(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"ab", "[Aa][Bb]"},
+		{"1.", "1."},
+		{`\d+[A-Z]`, "\\d+[A-Za-z\u017f\u212a]"},
+	}
+
+	for _, test := range tests {
+		r := NewFold(MustParse(test.pattern))
+		if got := r.String(); got != test.want {
+			t.Errorf("NewFold(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+		if back := MustParse(r.String()); termKey(back) != termKey(r) {
+			t.Errorf("NewFold(%q) does not round trip: %v", test.pattern, back)
+		}
+	}
+}
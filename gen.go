@@ -0,0 +1,159 @@
+package dr
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// GoOptions configures WriteGo.
+type GoOptions struct {
+	// Package is the package name of the generated file.
+	Package string
+
+	// Func is the name of the generated function.
+	Func string
+
+	// Generator is the name of the program mentioned in the
+	// "Code generated" header. If empty, "dr" is used.
+	Generator string
+}
+
+// WriteGo writes a Go source file containing a function with the signature
+//
+//	func Name(s string) bool
+//
+// which returns true if s is matched by the DFA. The function is a
+// switch-based state machine, which has no dependency on this package
+// and does not allocate. If the DFA is incomplete, ErrIncomplete is
+// returned.
+func WriteGo(w io.Writer, d *DFA, opts GoOptions) error {
+	if !d.complete() {
+		return ErrIncomplete
+	}
+
+	generator := opts.Generator
+	if generator == "" {
+		generator = "dr"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by %v. DO NOT EDIT.\n\n", generator)
+	fmt.Fprintf(&buf, "package %v\n\n", opts.Package)
+	if d.terms != nil {
+		fmt.Fprintf(&buf, "// %v returns true if s matches the regex %v.\n", opts.Func, strconv.Quote(d.terms[0].String()))
+	} else {
+		fmt.Fprintf(&buf, "// %v returns true if s matches the DFA.\n", opts.Func)
+	}
+	fmt.Fprintf(&buf, "func %v(s string) bool {\n", opts.Func)
+
+	if d.dead(0) || d.full(0) {
+		fmt.Fprintf(&buf, "return %v\n}\n", d.accept[0])
+		return writeFormatted(w, buf.Bytes())
+	}
+
+	buf.WriteString("state := 0\n")
+	buf.WriteString("for _, c := range s {\n")
+	buf.WriteString("switch state {\n")
+
+	for i := range d.accept {
+		if d.dead(i) || d.full(i) {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "case %v:\n", i)
+		writeGoState(&buf, d, i)
+	}
+
+	buf.WriteString("}\n}\n")
+
+	var accepting []string
+	for i, a := range d.accept {
+		if a {
+			accepting = append(accepting, strconv.Itoa(i))
+		}
+	}
+
+	if len(accepting) == 0 {
+		buf.WriteString("return false\n}\n")
+	} else {
+		buf.WriteString("switch state {\n")
+		fmt.Fprintf(&buf, "case %v:\n", strings.Join(accepting, ", "))
+		buf.WriteString("return true\n}\nreturn false\n}\n")
+	}
+
+	return writeFormatted(w, buf.Bytes())
+}
+
+// writeGoState writes a switch over c for the transitions of state i.
+// Transitions to the most common target become the default case.
+func writeGoState(buf *bytes.Buffer, d *DFA, i int) {
+	edges := d.edges(i)
+
+	def := 0
+	for j, e := range edges {
+		if e.width > edges[def].width {
+			def = j
+		}
+	}
+
+	if len(edges) == 1 {
+		writeGoTransition(buf, d, i, edges[def].to)
+		return
+	}
+
+	buf.WriteString("switch {\n")
+	for j, e := range edges {
+		if j == def {
+			continue
+		}
+
+		var conds []string
+		for _, r := range e.ranges {
+			if r[0] == r[1] {
+				conds = append(conds, "c == "+goRune(r[0]))
+			} else {
+				conds = append(conds, "c >= "+goRune(r[0])+" && c <= "+goRune(r[1]))
+			}
+		}
+
+		fmt.Fprintf(buf, "case %v:\n", strings.Join(conds, ", "))
+		writeGoTransition(buf, d, i, e.to)
+	}
+	buf.WriteString("default:\n")
+	writeGoTransition(buf, d, i, edges[def].to)
+	buf.WriteString("}\n")
+}
+
+func writeGoTransition(buf *bytes.Buffer, d *DFA, from, to int) {
+	switch {
+	case d.dead(to):
+		buf.WriteString("return false\n")
+	case d.full(to):
+		buf.WriteString("return true\n")
+	case from != to:
+		fmt.Fprintf(buf, "state = %v\n", to)
+	}
+}
+
+// goRune returns a Go literal for r. Surrogate halves are written as
+// integers, since they have no rune literal.
+func goRune(r rune) string {
+	if !utf8.ValidRune(r) {
+		return fmt.Sprintf("%#x", r)
+	}
+	return strconv.QuoteRune(r)
+}
+
+func writeFormatted(w io.Writer, src []byte) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
@@ -0,0 +1,173 @@
+package dr
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var genPatterns = []string{
+	"abc*+aad",
+	"(a+b)*abb",
+	"!(a+b*(asd(!d)))+(def)*",
+	"(a+ab)*(b+ba)*",
+	".*a..",
+	"!(.*)",
+	"é(ü+\\*)*",
+}
+
+// sampleInputs returns random strings built from the characters in the
+// pattern, plus a few others.
+func sampleInputs(pattern string, n int, rnd *rand.Rand) []string {
+	alphabet := []rune(pattern + "xyz")
+	inputs := []string{""}
+	for i := 0; i < n; i++ {
+		var buf bytes.Buffer
+		for j := rnd.Intn(8); j > 0; j-- {
+			buf.WriteRune(alphabet[rnd.Intn(len(alphabet))])
+		}
+		inputs = append(inputs, buf.String())
+	}
+	return inputs
+}
+
+func TestDFAMatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, p := range genPatterns {
+		r := MustParse(p)
+		d := MustCompile(r, 0)
+		for _, s := range sampleInputs(p, 500, rnd) {
+			if got, want := d.Match(s), Match(r, s); got != want {
+				t.Errorf("Compile(%q).Match(%q) = %v, want %v", p, s, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileStateLimit(t *testing.T) {
+	r := MustParse("(a+b)*a(a+b)(a+b)(a+b)(a+b)")
+	if _, err := Compile(r, 8); err != ErrStateLimit {
+		t.Errorf("got error %v, want ErrStateLimit", err)
+	}
+	if _, err := Compile(r, 0); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+}
+
+func TestExplore(t *testing.T) {
+	r := MustParse("(a+b)*a(a+b)(a+b)(a+b)(a+b)")
+	d, complete := Explore(r, ExploreOptions{MaxStates: 8})
+	if complete {
+		t.Error("Explore with a limit of 8 states is complete")
+	}
+	if d.NumStates() <= 8 || !d.Expanded(7) || d.Expanded(8) {
+		t.Errorf("got %v states, want more than 8 with the first 8 expanded", d.NumStates())
+	}
+	if d.Transitions(8) != nil {
+		t.Errorf("unexpanded state has transitions %v", d.Transitions(8))
+	}
+	for _, s := range []string{"", "a", "aabab", "babbbbbb", "bbabbab", "abbbb"} {
+		if got, want := d.Match(s), Match(r, s); got != want {
+			t.Errorf("incomplete DFA matches %q = %v, want %v", s, got, want)
+		}
+		if got, want := d.MatchBytes([]byte(s)), Match(r, s); got != want {
+			t.Errorf("incomplete DFA matches bytes %q = %v, want %v", s, got, want)
+		}
+	}
+	if err := WriteGo(ioutil.Discard, d, GoOptions{Package: "p", Func: "F"}); err != ErrIncomplete {
+		t.Errorf("WriteGo of incomplete DFA returned %v, want ErrIncomplete", err)
+	}
+	if _, err := d.MarshalBinary(); err != ErrIncomplete {
+		t.Errorf("MarshalBinary of incomplete DFA returned %v, want ErrIncomplete", err)
+	}
+
+	d, complete = Explore(MustParse("a*b.*"), ExploreOptions{Alphabet: "ab"})
+	if !complete {
+		t.Fatal("Explore is not complete")
+	}
+	if got, want := d.State(0).String(), "a*b.*"; got != want {
+		t.Errorf("State(0) = %q, want %q", got, want)
+	}
+
+	var got []string
+	for _, tr := range d.Transitions(0) {
+		got = append(got, fmt.Sprintf("%v:%v", tr, d.State(tr.To)))
+	}
+	want := []string{"[^ab]:∅", "a:a*b.*", "b:.*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transitions(0) = %v, want %v", got, want)
+	}
+	if d.Match("abc") || !d.Match("aabba") {
+		t.Error("DFA restricted to ab matches the wrong strings")
+	}
+}
+
+func TestWriteGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go run in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	dir, err := ioutil.TempDir("", "drgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rnd := rand.New(rand.NewSource(1))
+	var main, want bytes.Buffer
+	main.WriteString("package main\n\nimport \"fmt\"\n\nfunc main() {\n")
+
+	for i, p := range genPatterns {
+		r := MustParse(p)
+		name := fmt.Sprintf("Match%d", i)
+
+		var src bytes.Buffer
+		if err := WriteGo(&src, MustCompile(r, 0), GoOptions{Package: "main", Func: name}); err != nil {
+			t.Fatalf("WriteGo(%q): %v", p, err)
+		}
+		file := filepath.Join(dir, strings.ToLower(name)+".go")
+		if err := ioutil.WriteFile(file, src.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range sampleInputs(p, 100, rnd) {
+			fmt.Fprintf(&main, "fmt.Println(%v(%v))\n", name, strconv.Quote(s))
+			fmt.Fprintln(&want, Match(r, s))
+		}
+	}
+
+	main.WriteString("}\n")
+	files := map[string]string{
+		"main.go": main.String(),
+		"go.mod":  "module drgentest\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GO111MODULE=on")
+	got, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, got)
+	}
+
+	if string(got) != want.String() {
+		t.Errorf("generated matchers disagree with Match:\n%s", got)
+	}
+}
@@ -0,0 +1,203 @@
+package dr
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// JSONVersion is the version of the format written by EncodeJSON.
+const JSONVersion = 1
+
+// jsonRegex is the top level of the JSON format.
+type jsonRegex struct {
+	Version int       `json:"version"`
+	Regex   *jsonNode `json:"regex"`
+}
+
+// jsonNode is a tagged union of every kind of node. Binary operators
+// use l and r, unary operators use x, and chars, classes and anchors
+// use c. Lookaheads use x, with "?=" or "?!" in c.
+//
+// The derivatives returned by Derive are encoded as the regex they match,
+// without their location in the input.
+type jsonNode struct {
+	Op string    `json:"op"`
+	C  string    `json:"c,omitempty"`
+	L  *jsonNode `json:"l,omitempty"`
+	R  *jsonNode `json:"r,omitempty"`
+	X  *jsonNode `json:"x,omitempty"`
+}
+
+// EncodeJSON encodes the structure of a regex as JSON, for example:
+//
+//	{"version":1,"regex":{"op":"concat","l":{"op":"char","c":"a"},"r":{"op":"any"}}}
+//
+// Op is the name of the node's Kind. Regexes not created by this package
+// can't be encoded.
+func EncodeJSON(r Regex) ([]byte, error) {
+	n, err := toJSONNode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&jsonRegex{
+		Version: JSONVersion,
+		Regex:   n,
+	})
+}
+
+func toJSONNode(r Regex) (*jsonNode, error) {
+	if l, ok := r.(*located); ok {
+		r = l.r
+	}
+	n := &jsonNode{Op: KindOf(r).String()}
+
+	switch r := r.(type) {
+	case *empty, *epsilon, *any:
+	case *char:
+		if !utf8.ValidRune(r.r) {
+			return nil, fmt.Errorf("cannot encode invalid rune %U", r.r)
+		}
+		n.C = string(r.r)
+	case *class:
+		n.C = r.name
+	case *anchor:
+		n.C = r.String()
+	case *union, *intersection, *concat:
+		children := Children(r)
+		l, err := toJSONNode(children[0])
+		if err != nil {
+			return nil, err
+		}
+		rr, err := toJSONNode(children[1])
+		if err != nil {
+			return nil, err
+		}
+		n.L, n.R = l, rr
+	case *comp, *kleene, *lookahead:
+		x, err := toJSONNode(Children(r)[0])
+		if err != nil {
+			return nil, err
+		}
+		n.X = x
+		if l, ok := r.(*lookahead); ok {
+			n.C = "?="
+			if l.neg {
+				n.C = "?!"
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot encode regex of type %T", r)
+	}
+
+	return n, nil
+}
+
+// DecodeJSON decodes a regex written by EncodeJSON. A bare node without
+// the version wrapper is also accepted, and read as the current version.
+func DecodeJSON(data []byte) (Regex, error) {
+	var top struct {
+		jsonRegex
+		jsonNode
+	}
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+
+	if top.Op != "" {
+		return fromJSONNode(&top.jsonNode)
+	}
+
+	if top.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported JSON version %v", top.Version)
+	}
+	if top.Regex == nil {
+		return nil, fmt.Errorf("missing regex")
+	}
+	return fromJSONNode(top.Regex)
+}
+
+func fromJSONNode(n *jsonNode) (Regex, error) {
+	operand := func(name string, x *jsonNode) (Regex, error) {
+		if x == nil {
+			return nil, fmt.Errorf("%v node is missing %v", n.Op, name)
+		}
+		return fromJSONNode(x)
+	}
+
+	switch n.Op {
+	case "empty":
+		return NewEmpty(), nil
+	case "epsilon":
+		return NewEpsilon(), nil
+	case "any":
+		return NewAny(), nil
+
+	case "char":
+		c, size := utf8.DecodeRuneInString(n.C)
+		if n.C == "" || size != len(n.C) {
+			return nil, fmt.Errorf("char node must have exactly one character, got %q", n.C)
+		}
+		return NewChar(c), nil
+
+	case "class":
+		c, err := parseClass(n.C)
+		if err != nil {
+			return nil, fmt.Errorf("invalid class %q: %v", n.C, err)
+		}
+		return c, nil
+
+	case "anchor":
+		switch n.C {
+		case "^":
+			return NewStart(), nil
+		case "$":
+			return NewEnd(), nil
+		case `\b`, `\B`:
+			return NewWordBoundary(n.C == `\B`), nil
+		}
+		return nil, fmt.Errorf("unknown anchor %q", n.C)
+
+	case "union", "intersection", "concat":
+		l, err := operand("l", n.L)
+		if err != nil {
+			return nil, err
+		}
+		r, err := operand("r", n.R)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case "union":
+			return NewUnion(l, r), nil
+		case "intersection":
+			return NewIntersection(l, r), nil
+		}
+		return NewConcat(l, r), nil
+
+	case "comp", "kleene":
+		x, err := operand("x", n.X)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == "comp" {
+			return NewComp(x), nil
+		}
+		return NewKleene(x), nil
+
+	case "lookahead":
+		x, err := operand("x", n.X)
+		if err != nil {
+			return nil, err
+		}
+		switch n.C {
+		case "?=", "?!":
+			return NewLookahead(x, n.C == "?!"), nil
+		}
+		return nil, fmt.Errorf("unknown lookahead %q", n.C)
+
+	default:
+		return nil, fmt.Errorf("unknown op %q", n.Op)
+	}
+}
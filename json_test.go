@@ -0,0 +1,99 @@
+package dr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	data, err := EncodeJSON(MustParse("a.+!bc*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"version":1,"regex":{"op":"union",` +
+		`"l":{"op":"concat","l":{"op":"char","c":"a"},"r":{"op":"any"}},` +
+		`"r":{"op":"concat","l":{"op":"comp","x":{"op":"char","c":"b"}},"r":{"op":"kleene","x":{"op":"char","c":"c"}}}}}`
+	if string(data) != want {
+		t.Errorf("got %v, want %v", string(data), want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	regexes := []Regex{NewEmpty(), NewEpsilon(), NewConcat(NewChar('a'), NewEmpty())}
+	for i := 0; i < 1000; i++ {
+		regexes = append(regexes, randomRegex(rnd, 1+rnd.Intn(6)))
+	}
+
+	for _, r := range regexes {
+		data, err := EncodeJSON(r)
+		if err != nil {
+			t.Errorf("EncodeJSON(%v) returned %v", r, err)
+			continue
+		}
+
+		decoded, err := DecodeJSON(data)
+		if err != nil {
+			t.Errorf("DecodeJSON(%s) returned %v", data, err)
+			continue
+		}
+
+		if got, want := termKey(decoded), termKey(r); got != want {
+			t.Errorf("DecodeJSON(%s) = %v, structure %v, want %v", data, decoded, got, want)
+		}
+	}
+}
+
+func TestEncodeJSONDerivative(t *testing.T) {
+	d := Derive(MustParse(`a*\b(b+\B.)`), "aa")
+	data, err := EncodeJSON(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := EncodeJSON(d.(*located).r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(want) {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestEncodeJSONEscapes(t *testing.T) {
+	for _, p := range []string{`\u{D7FF}\u{E000}\u{10FFFF}`, `[\u{D7FF}-\u{E000}]`} {
+		if _, err := EncodeJSON(MustParse(p)); err != nil {
+			t.Errorf("EncodeJSON(%q) returned %v", p, err)
+		}
+	}
+}
+
+func TestDecodeJSONBareNode(t *testing.T) {
+	r, err := DecodeJSON([]byte(`{"op":"kleene","x":{"op":"char","c":"é"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.String(); got != "é*" {
+		t.Errorf("got %v, want é*", got)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		``,
+		`[]`,
+		`{}`,
+		`{"version":2,"regex":{"op":"any"}}`,
+		`{"version":1}`,
+		`{"version":1,"regex":{"op":"star"}}`,
+		`{"op":"char","c":"ab"}`,
+		`{"op":"char"}`,
+		`{"op":"union","l":{"op":"any"}}`,
+		`{"op":"comp"}`,
+	} {
+		if r, err := DecodeJSON([]byte(data)); err == nil {
+			t.Errorf("DecodeJSON(%s) = %v, want error", data, r)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package dr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LexRule is a named token definition for a Lexer.
+type LexRule struct {
+	Name  string
+	Regex Regex
+}
+
+// Token is a piece of input matched by a Lexer rule.
+type Token struct {
+	Rule int    // index of the matching rule
+	Name string // name of the matching rule
+	Text string
+
+	Offset int // byte offset of the start of the token
+	Line   int // line number, starting at 1
+	Column int // column in runes, starting at 1
+}
+
+// LexError is returned when no rule matches the input.
+type LexError struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("no rule matches input at line %v column %v (offset %v)", e.Line, e.Column, e.Offset)
+}
+
+// Lexer splits input into tokens using maximal munch: at each point, the
+// longest prefix matched by any rule becomes the next token, and ties go
+// to the rule that comes first. Rules that only match the empty string
+// never produce tokens.
+//
+// All rules are run together as a lazily built DFA, which is cached and
+// shared by every Scanner created from the Lexer. A Lexer is safe for
+// concurrent use, but a Scanner must only be used by one goroutine at a
+// time.
+type Lexer struct {
+	rules []LexRule
+	set   *Set
+}
+
+// NewLexer creates a Lexer from the given rules, in priority order.
+func NewLexer(rules []LexRule) *Lexer {
+	rs := make([]Regex, len(rules))
+	for i, r := range rules {
+		rs[i] = r.Regex
+	}
+
+	return &Lexer{
+		rules: rules,
+		set:   NewSet(rs),
+	}
+}
+
+// Tokenize splits the string into tokens.
+func (l *Lexer) Tokenize(s string) ([]Token, error) {
+	sc := l.Scanner(strings.NewReader(s))
+
+	var tokens []Token
+	for {
+		t, err := sc.Next()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+// Scanner creates a Scanner which reads tokens from r.
+func (l *Lexer) Scanner(r io.Reader) *Scanner {
+	rr, ok := r.(io.RuneReader)
+	if !ok {
+		rr = bufio.NewReader(r)
+	}
+
+	return &Scanner{
+		l:      l,
+		r:      rr,
+		line:   1,
+		column: 1,
+	}
+}
+
+// Scanner reads a stream of tokens.
+type Scanner struct {
+	l *Lexer
+	r io.RuneReader
+
+	// Runes which have been read but not yet consumed by a token,
+	// and their sizes in bytes.
+	buf   []rune
+	sizes []int
+	eof   bool
+	err   error // from reading ahead, returned by the next call to Next
+
+	prev   side // the kind of the last rune consumed
+	offset int
+	line   int
+	column int
+}
+
+// Next returns the next token. At the end of the input, it returns
+// io.EOF. If no rule matches, it returns a *LexError.
+//
+// Anchors and lookaheads in the rules see the input around the token, so
+// a rule ending in \b only matches before a boundary, and one ending in
+// $ only at the end of the input.
+func (s *Scanner) Next() (Token, error) {
+	set := s.l.set
+	st := set.start[s.prev]
+	rule, length := -1, 0
+
+	for n := 0; ; n++ {
+		c, _, ok := s.at(n)
+		if !ok {
+			break
+		}
+
+		st = set.step(st, c)
+		if st.dead {
+			break
+		}
+		if i := st.firstIn(s, n+1); i >= 0 {
+			rule, length = i, n+1
+		}
+	}
+
+	if err := s.err; err != nil {
+		s.err = nil
+		return Token{}, err
+	}
+
+	if len(s.buf) == 0 {
+		return Token{}, io.EOF
+	}
+
+	if rule < 0 {
+		return Token{}, &LexError{
+			Offset: s.offset,
+			Line:   s.line,
+			Column: s.column,
+		}
+	}
+
+	t := Token{
+		Rule:   rule,
+		Name:   s.l.rules[rule].Name,
+		Text:   string(s.buf[:length]),
+		Offset: s.offset,
+		Line:   s.line,
+		Column: s.column,
+	}
+	s.consume(length)
+	return t, nil
+}
+
+// at returns the rune at index i of the buffer, reading more input if
+// needed, so the Scanner can be used as an input to look ahead in. A read
+// error is kept for Next to return, and ends the input until then.
+func (s *Scanner) at(i int) (rune, int, bool) {
+	for i >= len(s.buf) && !s.eof && s.err == nil {
+		s.err = s.read()
+	}
+	if i >= len(s.buf) {
+		return 0, i, false
+	}
+	return s.buf[i], i + 1, true
+}
+
+func (s *Scanner) read() error {
+	if s.eof {
+		return nil
+	}
+
+	c, size, err := s.r.ReadRune()
+	if err == io.EOF {
+		s.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.buf = append(s.buf, c)
+	s.sizes = append(s.sizes, size)
+	return nil
+}
+
+func (s *Scanner) consume(n int) {
+	if n > 0 {
+		s.prev = sideOf(s.buf[n-1])
+	}
+	for i, c := range s.buf[:n] {
+		s.offset += s.sizes[i]
+		if c == '\n' {
+			s.line++
+			s.column = 1
+		} else {
+			s.column++
+		}
+	}
+
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	s.sizes = append(s.sizes[:0], s.sizes[n:]...)
+}
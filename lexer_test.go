@@ -0,0 +1,139 @@
+package dr
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+var testLexer = NewLexer([]LexRule{
+	{"comment", MustParse(`/\*!(.*\*/.*)\*/`)},
+	{"if", MustParse("if")},
+	{"ident", MustParse("(a+b+c+f+i+x+y)(a+b+c+f+i+x+y+0+1)*")},
+	{"number", MustParse("(0+1)(0+1)*")},
+	{"op", MustParse("=+==+/+\\*")},
+	{"space", MustParse("( +\n)( +\n)*")},
+})
+
+func TestLexerTokenize(t *testing.T) {
+	tokens, err := testLexer.Tokenize("if x == 10 /* a * b */\niffy = x/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, tok := range tokens {
+		if tok.Name != "space" {
+			got = append(got, tok.Name+":"+tok.Text)
+		}
+	}
+
+	want := []string{
+		"if:if",
+		"ident:x",
+		"op:==",
+		"number:10",
+		"comment:/* a * b */",
+		"ident:iffy",
+		"op:=",
+		"ident:x",
+		"op:/",
+		"ident:y",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	last := tokens[len(tokens)-1]
+	if last.Offset != 32 || last.Line != 2 || last.Column != 10 {
+		t.Errorf("last token at offset %v line %v column %v, want 32, 2, 10", last.Offset, last.Line, last.Column)
+	}
+}
+
+func TestLexerError(t *testing.T) {
+	tokens, err := testLexer.Tokenize("x = 1\ny = 2")
+	lexErr, ok := err.(*LexError)
+	if !ok {
+		t.Fatalf("got error %v, want *LexError", err)
+	}
+	if lexErr.Offset != 10 || lexErr.Line != 2 || lexErr.Column != 5 {
+		t.Errorf("got error at %v, want offset 10 line 2 column 5", lexErr)
+	}
+	if len(tokens) != 10 {
+		t.Errorf("got %v tokens before the error, want 10", len(tokens))
+	}
+}
+
+func TestScannerReader(t *testing.T) {
+	sc := testLexer.Scanner(strings.NewReader("/* ab */ #"))
+
+	tok, err := sc.Next()
+	if err != nil || tok.Text != "/* ab */" {
+		t.Fatalf("got %v, %v, want comment", tok, err)
+	}
+	if _, err := sc.Next(); err != nil {
+		t.Fatalf("got %v, want space", err)
+	}
+	if tok, err := sc.Next(); err == nil || tok.Text != "" {
+		t.Fatalf("got %v, %v, want error", tok, err)
+	}
+}
+
+func TestLexerContext(t *testing.T) {
+	l := NewLexer([]LexRule{
+		{"start", MustParse(`^#`)},
+		{"if", MustParse(`if\b`)},
+		{"end", MustParse(`x$`)},
+		{"call", MustParse(`[a-z][a-z]*(?=\()`)},
+		{"ident", MustParse(`[a-z][a-z]*`)},
+		{"punct", MustParse(`[#() ]`)},
+	})
+
+	sc := l.Scanner(iotest.OneByteReader(strings.NewReader("##ifx f(x) if x")))
+	var got []string
+	for {
+		tok, err := sc.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.Name != "punct" {
+			got = append(got, tok.Name+":"+tok.Text)
+		}
+	}
+
+	want := []string{"start:#", "ident:ifx", "call:f", "ident:x", "if:if", "end:x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLexerConcurrent(t *testing.T) {
+	l := NewLexer(testLexer.rules)
+	input := "if x == 10 /* a * b */\niffy = x/y"
+	want, err := testLexer.Tokenize(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				got, err := l.Tokenize(input)
+				if err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("Tokenize = %v, %v, want %v", got, err, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,184 @@
+package dr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is wrapped by every *LimitError, so errors.Is can check
+// whether matching failed because of Limits.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError is returned when matching exceeds one of its Limits.
+type LimitError struct {
+	Limit string // "size", "steps" or "memory"
+	Max   int    // the value of the limit
+	Step  int    // the number of characters derived so far
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v limit of %v exceeded after %v steps", e.Limit, e.Max, e.Step)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Limits bound the work done when matching a regex whose derivatives grow
+// too quickly, as they can with nested complements and stars. A zero
+// field means no limit.
+type Limits struct {
+	// MaxSize limits the number of nodes in each derivative, counted as
+	// if printed, so shared subterms count every time they appear.
+	MaxSize int
+
+	// MaxSteps limits the number of derivatives taken, one per character
+	// of the input.
+	MaxSteps int
+
+	// MaxMemory limits the estimated number of bytes held by each
+	// derivative, counting shared subterms once.
+	MaxMemory int
+}
+
+// DefaultLimits are the limits used by MatchContext. They allow terms far
+// larger than any reasonable pattern needs, while keeping a runaway match
+// to tens of megabytes.
+var DefaultLimits = Limits{
+	MaxSize:   1 << 20,
+	MaxMemory: 64 << 20,
+}
+
+// MatchContext is like Match, but stops with ctx.Err() if the context is
+// done, or a *LimitError if matching exceeds DefaultLimits.
+func MatchContext(ctx context.Context, r Regex, s string) (bool, error) {
+	return DefaultLimits.Match(ctx, r, s)
+}
+
+// Match is like MatchContext, but with these limits. Derivatives are
+// simplified after each step, as in Derive, to keep them small. Since
+// simplifying walks a term as a tree, each term is checked before it is
+// simplified as well as after, so sharing can't hide its size.
+func (l Limits) Match(ctx context.Context, r Regex, s string) (bool, error) {
+	if err := l.check(r, 0); err != nil {
+		return false, err
+	}
+	r = simplify(locate(r))
+	if err := l.check(r, 0); err != nil {
+		return false, err
+	}
+
+	step := 0
+	for _, c := range s {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		default:
+		}
+
+		if l.MaxSteps > 0 && step >= l.MaxSteps {
+			return false, &LimitError{Limit: "steps", Max: l.MaxSteps, Step: step}
+		}
+		step++
+
+		var err error
+		if r, err = l.derive(r, c, step); err != nil {
+			return false, err
+		}
+	}
+	return r.Accepting(), nil
+}
+
+// derive returns the simplified derivative of r with respect to c,
+// checking it before and after simplifying. A located regex simplifies
+// its own derivatives, so its location is tracked here instead.
+func (l Limits) derive(r Regex, c rune, step int) (Regex, error) {
+	loc, ok := r.(*located)
+	var d Regex
+	if ok {
+		d = derivativeAt(loc.r, loc.prev, c)
+	} else {
+		d = r.Derivative(c)
+	}
+	if err := l.check(d, step); err != nil {
+		return nil, err
+	}
+
+	d = simplify(d)
+	if ok {
+		d = locateAt(d, sideOf(c))
+	}
+	return d, l.check(d, step)
+}
+
+// check returns a *LimitError if r is too large.
+func (l Limits) check(r Regex, step int) error {
+	if l.MaxSize <= 0 && l.MaxMemory <= 0 {
+		return nil
+	}
+
+	m := measure(r, l.MaxSize)
+	if l.MaxSize > 0 && m.size > l.MaxSize {
+		return &LimitError{Limit: "size", Max: l.MaxSize, Step: step}
+	}
+	if l.MaxMemory > 0 && m.bytes > l.MaxMemory {
+		return &LimitError{Limit: "memory", Max: l.MaxMemory, Step: step}
+	}
+	return nil
+}
+
+// measurement is the size of a term as a tree, and the estimated memory
+// used by its distinct nodes.
+type measurement struct {
+	size  int
+	bytes int
+}
+
+// measure measures r in time proportional to its distinct nodes, even
+// when sharing makes it exponentially larger as a tree. If limit is
+// positive, larger sizes are reported as limit+1, so they can't overflow.
+func measure(r Regex, limit int) measurement {
+	sizes := make(map[Regex]int)
+	var m measurement
+
+	var visit func(r Regex) int
+	visit = func(r Regex) int {
+		if n, ok := sizes[r]; ok {
+			return n
+		}
+
+		n := 1
+		for _, c := range Children(r) {
+			n += visit(c)
+			if limit > 0 && n > limit {
+				n = limit + 1
+			}
+		}
+
+		sizes[r] = n
+		m.bytes += nodeBytes(r)
+		return n
+	}
+
+	m.size = visit(r)
+	return m
+}
+
+// nodeBytes estimates the memory held by a single node, not counting its
+// children. Classes share their tables with the parsed regex, and the
+// nodes without fields take no memory at all.
+func nodeBytes(r Regex) int {
+	const iface = 16 // an interface value
+	switch r.(type) {
+	case *union, *intersection, *concat:
+		return 2 * iface
+	case *comp, *kleene, *located, *lookahead:
+		return iface + 8
+	case *char, *anchor, *class:
+		return 8
+	default:
+		return 0
+	}
+}
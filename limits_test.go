@@ -0,0 +1,115 @@
+package dr
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMatchContext(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, p := range genPatterns {
+		r := MustParse(p)
+		for _, s := range sampleInputs(p, 100, rnd) {
+			got, err := MatchContext(context.Background(), r, s)
+			if err != nil {
+				t.Errorf("MatchContext(%q, %q) returned %v", p, s, err)
+				continue
+			}
+			if want := Match(r, s); got != want {
+				t.Errorf("MatchContext(%q, %q) = %v, want %v", p, s, got, want)
+			}
+		}
+	}
+}
+
+func TestMatchContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := MatchContext(ctx, MustParse("a*"), "aaa"); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	r := MustParse("(a+b)*a(a+b)(a+b)(a+b)")
+	size := measure(simplify(r), 0).size
+
+	tests := []struct {
+		limits Limits
+		limit  string
+	}{
+		{Limits{MaxSteps: 3}, "steps"},
+		{Limits{MaxSize: size}, "size"},
+		{Limits{MaxMemory: 64}, "memory"},
+	}
+
+	for _, test := range tests {
+		_, err := test.limits.Match(context.Background(), r, "abababab")
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%+v: got %v, want ErrLimitExceeded", test.limits, err)
+			continue
+		}
+		if le := err.(*LimitError); le.Limit != test.limit {
+			t.Errorf("%+v: exceeded %v limit, want %v", test.limits, le.Limit, test.limit)
+		}
+	}
+
+	if ok, err := (Limits{MaxSteps: 8}).Match(context.Background(), r, "abababab"); !ok || err != nil {
+		t.Errorf("Match at the step limit = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestLimitsShared(t *testing.T) {
+	r := NewChar('a')
+	for i := 0; i < 100; i++ {
+		r = NewConcat(r, r)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := MatchContext(context.Background(), r, "a")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if le, ok := err.(*LimitError); !ok || le.Limit != "size" || le.Step != 0 {
+			t.Errorf("got %v, want size limit exceeded after 0 steps", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("MatchContext didn't check the size before simplifying")
+	}
+}
+
+func TestLimitsBeforeSimplify(t *testing.T) {
+	r := MustParse("a*a*a*a*a*b")
+	d := simplify(r).Derivative('a')
+	raw, simplified := measure(d, 0).size, measure(simplify(d), 0).size
+	if raw <= simplified {
+		t.Fatalf("raw derivative size %v, want more than %v", raw, simplified)
+	}
+
+	_, err := Limits{MaxSize: raw - 1}.Match(context.Background(), r, "a")
+	if le, ok := err.(*LimitError); !ok || le.Limit != "size" || le.Step != 1 {
+		t.Errorf("got %v, want size limit exceeded after 1 step", err)
+	}
+}
+
+func TestMeasureShared(t *testing.T) {
+	r := NewChar('a')
+	for i := 0; i < 100; i++ {
+		r = NewConcat(r, r)
+	}
+
+	m := measure(r, 1000)
+	if m.size != 1001 {
+		t.Errorf("size = %v, want 1001", m.size)
+	}
+	if want := 100*nodeBytes(r) + nodeBytes(NewChar('a')); m.bytes != want {
+		t.Errorf("bytes = %v, want %v", m.bytes, want)
+	}
+}
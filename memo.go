@@ -0,0 +1,153 @@
+package dr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMemoNodes is the number of distinct derivatives a regex from
+// Memoize caches before it stops caching new ones.
+const DefaultMemoNodes = 10000
+
+// memoSlots is the number of derivatives each node remembers. Runes share
+// slots by their value modulo memoSlots, so a node remembers the most
+// recent rune in each slot.
+const memoSlots = 32
+
+// Memoize returns a regex equivalent to r which caches its derivatives.
+// Derivatives are simplified and interned, so equal terms are the same
+// node, and each node remembers its derivatives for the runes it has
+// seen recently. Repeated calls to Match with the returned regex then
+// reuse the work of earlier calls, much like a lazily built DFA, while
+// still being an ordinary Regex.
+//
+// The cache holds at most DefaultMemoNodes distinct terms; derivatives
+// past that point are computed as usual, without caching. The returned
+// regex is safe for concurrent use, and looking up a cached derivative
+// takes no locks.
+func Memoize(r Regex) Regex {
+	t := &memoTable{
+		nodes: make(map[string]Regex),
+		max:   DefaultMemoNodes,
+	}
+	return t.intern(simplify(locate(r)))
+}
+
+// memoTable interns the derivatives of a memoized regex.
+type memoTable struct {
+	mu    sync.Mutex // guards nodes
+	nodes map[string]Regex
+	max   int
+}
+
+// intern returns the node in the table for r, adding a copy of r with a
+// derivative cache if there is none yet and the table isn't full. Nodes
+// whose derivatives are trivial to compute are returned as they are.
+func (t *memoTable) intern(r Regex) Regex {
+	switch r.(type) {
+	case *union, *intersection, *concat, *comp, *kleene, *located:
+	default:
+		return r
+	}
+	if memoOf(r) != nil {
+		return r
+	}
+
+	k := termKey(r)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n, ok := t.nodes[k]; ok {
+		return n
+	}
+	if len(t.nodes) >= t.max {
+		return r
+	}
+
+	memo := &derivCache{table: t}
+	var n Regex
+	switch r := r.(type) {
+	case *union:
+		c := *r
+		c.memo = memo
+		n = &c
+	case *intersection:
+		c := *r
+		c.memo = memo
+		n = &c
+	case *concat:
+		c := *r
+		c.memo = memo
+		n = &c
+	case *comp:
+		c := *r
+		c.memo = memo
+		n = &c
+	case *kleene:
+		c := *r
+		c.memo = memo
+		n = &c
+	case *located:
+		c := *r
+		c.memo = memo
+		n = &c
+	}
+	t.nodes[k] = n
+	return n
+}
+
+// memoOf returns the derivative cache of r, or nil if it has none.
+func memoOf(r Regex) *derivCache {
+	switch r := r.(type) {
+	case *union:
+		return r.memo
+	case *intersection:
+		return r.memo
+	case *concat:
+		return r.memo
+	case *comp:
+		return r.memo
+	case *kleene:
+		return r.memo
+	case *located:
+		return r.memo
+	default:
+		return nil
+	}
+}
+
+// derivCache remembers the derivatives of an interned node. A nil
+// *derivCache caches nothing, so nodes which aren't interned can use
+// it unconditionally.
+type derivCache struct {
+	table *memoTable
+	slots [memoSlots]atomic.Value // *memoEntry
+}
+
+type memoEntry struct {
+	c rune
+	d Regex
+}
+
+// lookup returns the cached derivative with respect to c, if any.
+func (m *derivCache) lookup(c rune) (Regex, bool) {
+	if m == nil {
+		return nil, false
+	}
+	if e, ok := m.slots[uint32(c)%memoSlots].Load().(*memoEntry); ok && e.c == c {
+		return e.d, true
+	}
+	return nil, false
+}
+
+// store caches d as the derivative with respect to c, returning the
+// interned node to use in its place.
+func (m *derivCache) store(c rune, d Regex) Regex {
+	if m == nil {
+		return d
+	}
+	d = m.table.intern(simplify(d))
+	m.slots[uint32(c)%memoSlots].Store(&memoEntry{c: c, d: d})
+	return d
+}
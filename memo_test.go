@@ -0,0 +1,90 @@
+package dr
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMemoize(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	patterns := append([]string{`^a*\b.*$`, `(?=.*b)a.*`, "[a-z]*&!(.*ab.*)"}, genPatterns...)
+
+	for _, p := range patterns {
+		r := MustParse(p)
+		m := Memoize(r)
+		for _, s := range sampleInputs(p, 200, rnd) {
+			want := Match(r, s)
+			for i := 0; i < 2; i++ {
+				if got := Match(m, s); got != want {
+					t.Errorf("Match(Memoize(%q), %q) = %v, want %v", p, s, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestMemoizeReuse(t *testing.T) {
+	m := Memoize(MustParse("(a+b)*abb"))
+
+	d := m.Derivative('a')
+	if memoOf(d) == nil {
+		t.Fatalf("derivative %v is not memoized", d)
+	}
+	if m.Derivative('a') != d {
+		t.Error("derivative is not cached")
+	}
+	if Derive(d, "bba") != d {
+		t.Error("equal derivatives are not interned")
+	}
+}
+
+func TestMemoizeBounded(t *testing.T) {
+	table := &memoTable{nodes: make(map[string]Regex), max: 3}
+	r := MustParse("(a+b)*a(a+b)(a+b)(a+b)")
+	m := table.intern(simplify(r))
+
+	rnd := rand.New(rand.NewSource(1))
+	for _, s := range sampleInputs("ab", 200, rnd) {
+		if got, want := Match(m, s), Match(r, s); got != want {
+			t.Errorf("Match(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if len(table.nodes) > 3 {
+		t.Errorf("table has %v nodes, want at most 3", len(table.nodes))
+	}
+}
+
+func TestMemoizeSearch(t *testing.T) {
+	r := MustParse(`\bab*`)
+	s := "ab cab abb"
+	if got, want := FindAllIndex(Memoize(r), s, -1), FindAllIndex(r, s, -1); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAllIndex = %v, want %v", got, want)
+	}
+}
+
+func TestMemoizeConcurrent(t *testing.T) {
+	r := MustParse("!(a+b*(asd(!d)))+(def)*")
+	m := Memoize(r)
+
+	inputs := sampleInputs("!(a+b*(asd(!d)))+(def)*", 500, rand.New(rand.NewSource(1)))
+	want := make([]bool, len(inputs))
+	for i, s := range inputs {
+		want[i] = Match(r, s)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, s := range inputs {
+				if got := Match(m, s); got != want[i] {
+					t.Errorf("Match(%q) = %v, want %v", s, got, want[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package dr
+
+// maxSize is the largest size Size reports, leaving room to add two sizes
+// without overflow.
+const maxSize = int(^uint(0)>>2) - 1
+
+// Size returns the number of nodes in r, counted as if printed, so a
+// subterm shared by several parents counts once for each. Derivatives
+// share subterms heavily, so Size takes time proportional to the distinct
+// nodes, and sizes too large for an int are reported as a large int.
+func Size(r Regex) int {
+	return measure(r, maxSize).size
+}
+
+// Depth returns the number of nodes on the longest path from r to a
+// leaf, so a single character has depth 1.
+func Depth(r Regex) int {
+	depths := make(map[Regex]int)
+
+	var visit func(r Regex) int
+	visit = func(r Regex) int {
+		if d, ok := depths[r]; ok {
+			return d
+		}
+
+		d := 0
+		for _, c := range Children(r) {
+			if cd := visit(c); cd > d {
+				d = cd
+			}
+		}
+
+		depths[r] = d + 1
+		return d + 1
+	}
+
+	return visit(r)
+}
+
+// Trace follows Match, returning the Size of the regex before any input
+// and after each Derivative step, one per character of s. It shows how
+// the terms grow with the input, for finding the patterns which become
+// slow to match on long inputs.
+func Trace(r Regex, s string) []int {
+	r = locate(r)
+	sizes := []int{Size(r)}
+	for _, c := range s {
+		r = r.Derivative(c)
+		sizes = append(sizes, Size(r))
+	}
+	return sizes
+}
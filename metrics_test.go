@@ -0,0 +1,59 @@
+package dr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSizeDepth(t *testing.T) {
+	tests := []struct {
+		pattern string
+		size    int
+		depth   int
+	}{
+		{"a", 1, 1},
+		{"ab*c", 6, 4},
+		{"!((a+b)*)", 5, 4},
+		{"[a-z]&.", 3, 2},
+	}
+
+	for _, test := range tests {
+		r := MustParse(test.pattern)
+		if got := Size(r); got != test.size {
+			t.Errorf("Size(%q) = %v, want %v", test.pattern, got, test.size)
+		}
+		if got := Depth(r); got != test.depth {
+			t.Errorf("Depth(%q) = %v, want %v", test.pattern, got, test.depth)
+		}
+	}
+}
+
+func TestSizeShared(t *testing.T) {
+	r := NewChar('a')
+	for i := 0; i < 100; i++ {
+		r = NewConcat(r, r)
+	}
+
+	if got := Size(r); got < 1<<30 {
+		t.Errorf("Size = %v, want a large size", got)
+	}
+	if got := Depth(r); got != 101 {
+		t.Errorf("Depth = %v, want 101", got)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	r := MustParse("ab*c")
+	got := Trace(r, "abbc")
+
+	want := []int{Size(r)}
+	d := r
+	for _, c := range "abbc" {
+		d = d.Derivative(c)
+		want = append(want, Size(d))
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Trace = %v, want %v", got, want)
+	}
+}
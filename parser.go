@@ -1,17 +1,64 @@
 package dr
 
+import "fmt"
+
+// Options change how a pattern is parsed.
+type Options struct {
+	// CaseInsensitive makes characters and classes match regardless of
+	// case, as if the result were passed to NewFold.
+	CaseInsensitive bool
+
+	// Bytes makes the regex match bytes rather than characters; see
+	// ParseBytes.
+	Bytes bool
+
+	// Defs gives the regexes which {name} references stand for. A
+	// reference to a name not in Defs is a syntax error. See ParseDefs.
+	Defs map[string]Regex
+}
+
+// Parse parses a regex. If the pattern is malformed, the returned
+// error is a *SyntaxError.
 func Parse(s string) (Regex, error) {
-	p := parseTree{Buffer: s}
+	return ParseWithOptions(s, Options{})
+}
+
+// ParseWithOptions is like Parse, but with options.
+func ParseWithOptions(s string, opts Options) (Regex, error) {
+	return parse(s, opts, func(name string) (Regex, *classError) {
+		if r, ok := opts.Defs[name]; ok {
+			return r, nil
+		}
+		return nil, &classError{0, fmt.Sprintf("undefined name %q", name)}
+	})
+}
+
+// parse parses s, resolving references with lookup.
+func parse(s string, opts Options, lookup func(string) (Regex, *classError)) (Regex, error) {
+	p := parseTree{Buffer: s, regexTree: regexTree{bytes: opts.Bytes, lookup: lookup}}
 	p.Init()
 
 	if err := p.Parse(); err != nil {
-		return nil, err
+		return nil, syntaxError(s, err)
 	}
 
 	p.Execute()
-	return p.get(), nil
+	if e := p.err; e != nil {
+		offset := len(string([]rune(s)[:e.pos])) + e.offset
+		return nil, newSyntaxError(s, offset, e.problem)
+	}
+
+	r := p.get()
+	if opts.CaseInsensitive {
+		r = NewFold(r)
+	}
+	if opts.Bytes {
+		r = clipBytes(r)
+	}
+	return r, nil
 }
 
+// MustParse is like Parse, but panics on error.
 func MustParse(s string) Regex {
 	r, err := Parse(s)
 	if err != nil {
@@ -20,4 +67,19 @@ func MustParse(s string) Regex {
 	return r
 }
 
+// syntaxError converts an error from the generated parser into a
+// *SyntaxError. If the failure can't be explained, it points at the
+// furthest position the parser reached.
+func syntaxError(s string, err error) *SyntaxError {
+	if e := diagnose(s); e != nil {
+		return e
+	}
+
+	offset := len(s)
+	if pe, ok := err.(*parseError); ok {
+		offset = len(string([]rune(s)[:pe.max.end]))
+	}
+	return newSyntaxError(s, offset, "syntax error")
+}
+
 //go:generate peg -inline -switch peg.peg
@@ -17,20 +17,36 @@ const (
 	ruleRoot
 	ruleRegex
 	ruleUnion
+	ruleInter
 	ruleConcat
 	ruleUnary
 	ruleComp
 	ruleKleene
 	ruleFactor
+	ruleRef
 	ruleChar
+	ruleEscape
+	ruleHex
+	ruleClass
+	ruleAnchor
+	ruleLookahead
 	ruleAction0
 	ruleAction1
 	ruleAction2
 	ruleAction3
-	rulePegText
 	ruleAction4
+	rulePegText
 	ruleAction5
 	ruleAction6
+	ruleAction7
+	ruleAction8
+	ruleAction9
+	ruleAction10
+	ruleAction11
+	ruleAction12
+	ruleAction13
+	ruleAction14
+	ruleAction15
 )
 
 var rul3s = [...]string{
@@ -38,20 +54,36 @@ var rul3s = [...]string{
 	"Root",
 	"Regex",
 	"Union",
+	"Inter",
 	"Concat",
 	"Unary",
 	"Comp",
 	"Kleene",
 	"Factor",
+	"Ref",
 	"Char",
+	"Escape",
+	"Hex",
+	"Class",
+	"Anchor",
+	"Lookahead",
 	"Action0",
 	"Action1",
 	"Action2",
 	"Action3",
-	"PegText",
 	"Action4",
+	"PegText",
 	"Action5",
 	"Action6",
+	"Action7",
+	"Action8",
+	"Action9",
+	"Action10",
+	"Action11",
+	"Action12",
+	"Action13",
+	"Action14",
+	"Action15",
 }
 
 type token32 struct {
@@ -168,7 +200,7 @@ type parseTree struct {
 
 	Buffer string
 	buffer []rune
-	rules  [18]func() bool
+	rules  [34]func() bool
 	parse  func(rule ...int) error
 	reset  func()
 	Pretty bool
@@ -263,17 +295,35 @@ func (p *parseTree) Execute() {
 		case ruleAction0:
 			p.union()
 		case ruleAction1:
-			p.concat()
+			p.intersection()
 		case ruleAction2:
-			p.comp()
+			p.concat()
 		case ruleAction3:
-			p.kleene()
+			p.comp()
 		case ruleAction4:
-			p.char(firstRune(text))
+			p.kleene()
 		case ruleAction5:
-			p.char(lastRune(text))
+			p.ref(text, begin)
 		case ruleAction6:
+			p.char(firstRune(text), begin)
+		case ruleAction7:
+			p.char(lastRune(text), begin)
+		case ruleAction8:
+			p.escape(text, begin)
+		case ruleAction9:
 			p.any()
+		case ruleAction10:
+			p.class(text, begin)
+		case ruleAction11:
+			p.class(text, begin)
+		case ruleAction12:
+			p.class(text, begin)
+		case ruleAction13:
+			p.anchor(text)
+		case ruleAction14:
+			p.lookahead(false)
+		case ruleAction15:
+			p.lookahead(true)
 
 		}
 	}
@@ -379,14 +429,14 @@ func (p *parseTree) Init() {
 			position, tokenIndex = position3, tokenIndex3
 			return false
 		},
-		/* 2 Union <- <((Concat !('+' Union)) / (Concat '+' Union Action0))> */
+		/* 2 Union <- <((Inter !('+' Union)) / (Inter '+' Union Action0))> */
 		func() bool {
 			position5, tokenIndex5 := position, tokenIndex
 			{
 				position6 := position
 				{
 					position7, tokenIndex7 := position, tokenIndex
-					if !_rules[ruleConcat]() {
+					if !_rules[ruleInter]() {
 						goto l8
 					}
 					{
@@ -405,7 +455,7 @@ func (p *parseTree) Init() {
 					goto l7
 				l8:
 					position, tokenIndex = position7, tokenIndex7
-					if !_rules[ruleConcat]() {
+					if !_rules[ruleInter]() {
 						goto l5
 					}
 					if buffer[position] != rune('+') {
@@ -427,19 +477,23 @@ func (p *parseTree) Init() {
 			position, tokenIndex = position5, tokenIndex5
 			return false
 		},
-		/* 3 Concat <- <((Unary !Concat) / (Unary Concat Action1))> */
+		/* 3 Inter <- <((Concat !('&' Inter)) / (Concat '&' Inter Action1))> */
 		func() bool {
 			position11, tokenIndex11 := position, tokenIndex
 			{
 				position12 := position
 				{
 					position13, tokenIndex13 := position, tokenIndex
-					if !_rules[ruleUnary]() {
+					if !_rules[ruleConcat]() {
 						goto l14
 					}
 					{
 						position15, tokenIndex15 := position, tokenIndex
-						if !_rules[ruleConcat]() {
+						if buffer[position] != rune('&') {
+							goto l15
+						}
+						position++
+						if !_rules[ruleInter]() {
 							goto l15
 						}
 						goto l14
@@ -449,10 +503,14 @@ func (p *parseTree) Init() {
 					goto l13
 				l14:
 					position, tokenIndex = position13, tokenIndex13
-					if !_rules[ruleUnary]() {
+					if !_rules[ruleConcat]() {
 						goto l11
 					}
-					if !_rules[ruleConcat]() {
+					if buffer[position] != rune('&') {
+						goto l11
+					}
+					position++
+					if !_rules[ruleInter]() {
 						goto l11
 					}
 					{
@@ -460,258 +518,946 @@ func (p *parseTree) Init() {
 					}
 				}
 			l13:
-				add(ruleConcat, position12)
+				add(ruleInter, position12)
 			}
 			return true
 		l11:
 			position, tokenIndex = position11, tokenIndex11
 			return false
 		},
-		/* 4 Unary <- <(Comp / Kleene / Factor)> */
+		/* 4 Concat <- <((Unary !Concat) / (Unary Concat Action2))> */
 		func() bool {
 			position17, tokenIndex17 := position, tokenIndex
 			{
 				position18 := position
 				{
 					position19, tokenIndex19 := position, tokenIndex
+					if !_rules[ruleUnary]() {
+						goto l20
+					}
 					{
-						position21 := position
+						position21, tokenIndex21 := position, tokenIndex
+						if !_rules[ruleConcat]() {
+							goto l21
+						}
+						goto l20
+					l21:
+						position, tokenIndex = position21, tokenIndex21
+					}
+					goto l19
+				l20:
+					position, tokenIndex = position19, tokenIndex19
+					if !_rules[ruleUnary]() {
+						goto l17
+					}
+					if !_rules[ruleConcat]() {
+						goto l17
+					}
+					{
+						add(ruleAction2, position)
+					}
+				}
+			l19:
+				add(ruleConcat, position18)
+			}
+			return true
+		l17:
+			position, tokenIndex = position17, tokenIndex17
+			return false
+		},
+		/* 5 Unary <- <(Comp / Kleene / Factor)> */
+		func() bool {
+			position23, tokenIndex23 := position, tokenIndex
+			{
+				position24 := position
+				{
+					position25, tokenIndex25 := position, tokenIndex
+					{
+						position27 := position
 						if buffer[position] != rune('!') {
-							goto l20
+							goto l26
 						}
 						position++
 						if !_rules[ruleFactor]() {
-							goto l20
+							goto l26
 						}
 						{
-							add(ruleAction2, position)
+							add(ruleAction3, position)
 						}
-						add(ruleComp, position21)
+						add(ruleComp, position27)
 					}
-					goto l19
-				l20:
-					position, tokenIndex = position19, tokenIndex19
+					goto l25
+				l26:
+					position, tokenIndex = position25, tokenIndex25
 					{
-						position24 := position
+						position30 := position
 						if !_rules[ruleFactor]() {
-							goto l23
+							goto l29
 						}
 						if buffer[position] != rune('*') {
-							goto l23
+							goto l29
 						}
 						position++
 						{
-							add(ruleAction3, position)
+							add(ruleAction4, position)
 						}
-						add(ruleKleene, position24)
+						add(ruleKleene, position30)
 					}
-					goto l19
-				l23:
-					position, tokenIndex = position19, tokenIndex19
+					goto l25
+				l29:
+					position, tokenIndex = position25, tokenIndex25
 					if !_rules[ruleFactor]() {
-						goto l17
+						goto l23
 					}
 				}
-			l19:
-				add(ruleUnary, position18)
+			l25:
+				add(ruleUnary, position24)
 			}
 			return true
-		l17:
-			position, tokenIndex = position17, tokenIndex17
+		l23:
+			position, tokenIndex = position23, tokenIndex23
 			return false
 		},
-		/* 5 Comp <- <('!' Factor Action2)> */
+		/* 6 Comp <- <('!' Factor Action3)> */
 		nil,
-		/* 6 Kleene <- <(Factor '*' Action3)> */
+		/* 7 Kleene <- <(Factor '*' Action4)> */
 		nil,
-		/* 7 Factor <- <(Char / ('(' Regex ')'))> */
+		/* 8 Factor <- <(Ref / Char / Class / Anchor / Lookahead / ('(' Regex ')'))> */
 		func() bool {
-			position28, tokenIndex28 := position, tokenIndex
+			position34, tokenIndex34 := position, tokenIndex
 			{
-				position29 := position
+				position35 := position
 				{
-					position30, tokenIndex30 := position, tokenIndex
+					position36, tokenIndex36 := position, tokenIndex
 					{
-						position32 := position
+						position38 := position
 						{
-							position33, tokenIndex33 := position, tokenIndex
+							position39 := position
+							if buffer[position] != rune('{') {
+								goto l37
+							}
+							position++
+							{
+								switch buffer[position] {
+								case '_':
+									if buffer[position] != rune('_') {
+										goto l37
+									}
+									position++
+									break
+								case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
+									if c := buffer[position]; c < rune('A') || c > rune('Z') {
+										goto l37
+									}
+									position++
+									break
+								default:
+									if c := buffer[position]; c < rune('a') || c > rune('z') {
+										goto l37
+									}
+									position++
+									break
+								}
+							}
+
+						l41:
 							{
-								position35 := position
+								position42, tokenIndex42 := position, tokenIndex
 								{
-									position36, tokenIndex36 := position, tokenIndex
+									switch buffer[position] {
+									case '_':
+										if buffer[position] != rune('_') {
+											goto l42
+										}
+										position++
+										break
+									case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+										if c := buffer[position]; c < rune('0') || c > rune('9') {
+											goto l42
+										}
+										position++
+										break
+									case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
+										if c := buffer[position]; c < rune('A') || c > rune('Z') {
+											goto l42
+										}
+										position++
+										break
+									default:
+										if c := buffer[position]; c < rune('a') || c > rune('z') {
+											goto l42
+										}
+										position++
+										break
+									}
+								}
+
+								goto l41
+							l42:
+								position, tokenIndex = position42, tokenIndex42
+							}
+							if buffer[position] != rune('}') {
+								goto l37
+							}
+							position++
+							add(rulePegText, position39)
+						}
+						{
+							add(ruleAction5, position)
+						}
+						add(ruleRef, position38)
+					}
+					goto l36
+				l37:
+					position, tokenIndex = position36, tokenIndex36
+					{
+						position46 := position
+						{
+							position47, tokenIndex47 := position, tokenIndex
+							{
+								position49 := position
+								{
+									position50, tokenIndex50 := position, tokenIndex
 									{
 										switch buffer[position] {
+										case '$':
+											if buffer[position] != rune('$') {
+												goto l50
+											}
+											position++
+											break
+										case '^':
+											if buffer[position] != rune('^') {
+												goto l50
+											}
+											position++
+											break
+										case '[':
+											if buffer[position] != rune('[') {
+												goto l50
+											}
+											position++
+											break
 										case '.':
 											if buffer[position] != rune('.') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										case ')':
 											if buffer[position] != rune(')') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										case '(':
 											if buffer[position] != rune('(') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										case '\\':
 											if buffer[position] != rune('\\') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										case '!':
 											if buffer[position] != rune('!') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										case '*':
 											if buffer[position] != rune('*') {
-												goto l36
+												goto l50
+											}
+											position++
+											break
+										case '&':
+											if buffer[position] != rune('&') {
+												goto l50
 											}
 											position++
 											break
 										default:
 											if buffer[position] != rune('+') {
-												goto l36
+												goto l50
 											}
 											position++
 											break
 										}
 									}
 
-									goto l34
-								l36:
-									position, tokenIndex = position36, tokenIndex36
+									goto l48
+								l50:
+									position, tokenIndex = position50, tokenIndex50
 								}
 								if !matchDot() {
-									goto l34
+									goto l48
 								}
-								add(rulePegText, position35)
+								add(rulePegText, position49)
 							}
 							{
-								add(ruleAction4, position)
+								add(ruleAction6, position)
 							}
-							goto l33
-						l34:
-							position, tokenIndex = position33, tokenIndex33
+							goto l47
+						l48:
+							position, tokenIndex = position47, tokenIndex47
 							if buffer[position] != rune('\\') {
-								goto l39
+								goto l53
 							}
 							position++
 							{
-								position40 := position
+								position54 := position
 								{
 									switch buffer[position] {
+									case '{':
+										if buffer[position] != rune('{') {
+											goto l53
+										}
+										position++
+										break
+									case '$':
+										if buffer[position] != rune('$') {
+											goto l53
+										}
+										position++
+										break
+									case '^':
+										if buffer[position] != rune('^') {
+											goto l53
+										}
+										position++
+										break
+									case ']':
+										if buffer[position] != rune(']') {
+											goto l53
+										}
+										position++
+										break
+									case '[':
+										if buffer[position] != rune('[') {
+											goto l53
+										}
+										position++
+										break
+									case '?':
+										if buffer[position] != rune('?') {
+											goto l53
+										}
+										position++
+										break
 									case '.':
 										if buffer[position] != rune('.') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									case ')':
 										if buffer[position] != rune(')') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									case '(':
 										if buffer[position] != rune('(') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									case '\\':
 										if buffer[position] != rune('\\') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									case '!':
 										if buffer[position] != rune('!') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									case '*':
 										if buffer[position] != rune('*') {
-											goto l39
+											goto l53
+										}
+										position++
+										break
+									case '&':
+										if buffer[position] != rune('&') {
+											goto l53
 										}
 										position++
 										break
 									default:
 										if buffer[position] != rune('+') {
-											goto l39
+											goto l53
 										}
 										position++
 										break
 									}
 								}
 
-								add(rulePegText, position40)
+								add(rulePegText, position54)
+							}
+							{
+								add(ruleAction7, position)
+							}
+							goto l47
+						l53:
+							position, tokenIndex = position47, tokenIndex47
+							{
+								position58 := position
+								if buffer[position] != rune('\\') {
+									goto l57
+								}
+								position++
+								{
+									position59 := position
+									{
+										position60, tokenIndex60 := position, tokenIndex
+										{
+											switch buffer[position] {
+											case 'v':
+												if buffer[position] != rune('v') {
+													goto l61
+												}
+												position++
+												break
+											case 't':
+												if buffer[position] != rune('t') {
+													goto l61
+												}
+												position++
+												break
+											case 'r':
+												if buffer[position] != rune('r') {
+													goto l61
+												}
+												position++
+												break
+											case 'n':
+												if buffer[position] != rune('n') {
+													goto l61
+												}
+												position++
+												break
+											case 'f':
+												if buffer[position] != rune('f') {
+													goto l61
+												}
+												position++
+												break
+											default:
+												if buffer[position] != rune('a') {
+													goto l61
+												}
+												position++
+												break
+											}
+										}
+
+										goto l60
+									l61:
+										position, tokenIndex = position60, tokenIndex60
+										if c := buffer[position]; c < rune('0') || c > rune('7') {
+											goto l63
+										}
+										position++
+										{
+											position64, tokenIndex64 := position, tokenIndex
+											if c := buffer[position]; c < rune('0') || c > rune('7') {
+												goto l64
+											}
+											position++
+											goto l65
+										l64:
+											position, tokenIndex = position64, tokenIndex64
+										}
+									l65:
+										{
+											position66, tokenIndex66 := position, tokenIndex
+											if c := buffer[position]; c < rune('0') || c > rune('7') {
+												goto l66
+											}
+											position++
+											goto l67
+										l66:
+											position, tokenIndex = position66, tokenIndex66
+										}
+									l67:
+										goto l60
+									l63:
+										position, tokenIndex = position60, tokenIndex60
+										if buffer[position] != rune('x') {
+											goto l68
+										}
+										position++
+										if !_rules[ruleHex]() {
+											goto l68
+										}
+										if !_rules[ruleHex]() {
+											goto l68
+										}
+										goto l60
+									l68:
+										position, tokenIndex = position60, tokenIndex60
+										if buffer[position] != rune('u') {
+											goto l57
+										}
+										position++
+										if buffer[position] != rune('{') {
+											goto l57
+										}
+										position++
+										if !_rules[ruleHex]() {
+											goto l57
+										}
+									l69:
+										{
+											position70, tokenIndex70 := position, tokenIndex
+											if !_rules[ruleHex]() {
+												goto l70
+											}
+											goto l69
+										l70:
+											position, tokenIndex = position70, tokenIndex70
+										}
+										if buffer[position] != rune('}') {
+											goto l57
+										}
+										position++
+									}
+								l60:
+									add(ruleEscape, position59)
+								}
+								add(rulePegText, position58)
 							}
 							{
-								add(ruleAction5, position)
+								add(ruleAction8, position)
 							}
-							goto l33
-						l39:
-							position, tokenIndex = position33, tokenIndex33
+							goto l47
+						l57:
+							position, tokenIndex = position47, tokenIndex47
 							if buffer[position] != rune('.') {
-								goto l31
+								goto l45
 							}
 							position++
 							{
-								add(ruleAction6, position)
+								add(ruleAction9, position)
 							}
 						}
-					l33:
-						add(ruleChar, position32)
+					l47:
+						add(ruleChar, position46)
 					}
-					goto l30
-				l31:
-					position, tokenIndex = position30, tokenIndex30
+					goto l36
+				l45:
+					position, tokenIndex = position36, tokenIndex36
+					{
+						position74 := position
+						{
+							position75, tokenIndex75 := position, tokenIndex
+							{
+								position77 := position
+								if buffer[position] != rune('\\') {
+									goto l76
+								}
+								position++
+								{
+									switch buffer[position] {
+									case 'W':
+										if buffer[position] != rune('W') {
+											goto l76
+										}
+										position++
+										break
+									case 'S':
+										if buffer[position] != rune('S') {
+											goto l76
+										}
+										position++
+										break
+									case 'D':
+										if buffer[position] != rune('D') {
+											goto l76
+										}
+										position++
+										break
+									case 'w':
+										if buffer[position] != rune('w') {
+											goto l76
+										}
+										position++
+										break
+									case 's':
+										if buffer[position] != rune('s') {
+											goto l76
+										}
+										position++
+										break
+									default:
+										if buffer[position] != rune('d') {
+											goto l76
+										}
+										position++
+										break
+									}
+								}
+
+								add(rulePegText, position77)
+							}
+							{
+								add(ruleAction10, position)
+							}
+							goto l75
+						l76:
+							position, tokenIndex = position75, tokenIndex75
+							{
+								position81 := position
+								if buffer[position] != rune('\\') {
+									goto l80
+								}
+								position++
+								{
+									switch buffer[position] {
+									case 'P':
+										if buffer[position] != rune('P') {
+											goto l80
+										}
+										position++
+										break
+									default:
+										if buffer[position] != rune('p') {
+											goto l80
+										}
+										position++
+										break
+									}
+								}
+
+								{
+									position83, tokenIndex83 := position, tokenIndex
+									if buffer[position] != rune('{') {
+										goto l84
+									}
+									position++
+								l85:
+									{
+										position86, tokenIndex86 := position, tokenIndex
+										{
+											position87, tokenIndex87 := position, tokenIndex
+											if buffer[position] != rune('}') {
+												goto l87
+											}
+											position++
+											goto l86
+										l87:
+											position, tokenIndex = position87, tokenIndex87
+										}
+										if !matchDot() {
+											goto l86
+										}
+										goto l85
+									l86:
+										position, tokenIndex = position86, tokenIndex86
+									}
+									if buffer[position] != rune('}') {
+										goto l84
+									}
+									position++
+									goto l83
+								l84:
+									position, tokenIndex = position83, tokenIndex83
+									{
+										position88, tokenIndex88 := position, tokenIndex
+										if buffer[position] != rune('{') {
+											goto l88
+										}
+										position++
+										goto l80
+									l88:
+										position, tokenIndex = position88, tokenIndex88
+									}
+									if !matchDot() {
+										goto l80
+									}
+								}
+							l83:
+								add(rulePegText, position81)
+							}
+							{
+								add(ruleAction11, position)
+							}
+							goto l75
+						l80:
+							position, tokenIndex = position75, tokenIndex75
+							{
+								position90 := position
+								if buffer[position] != rune('[') {
+									goto l73
+								}
+								position++
+							l91:
+								{
+									position92, tokenIndex92 := position, tokenIndex
+									{
+										position93, tokenIndex93 := position, tokenIndex
+										if buffer[position] != rune('\\') {
+											goto l94
+										}
+										position++
+										if !matchDot() {
+											goto l94
+										}
+										goto l93
+									l94:
+										position, tokenIndex = position93, tokenIndex93
+										{
+											position95, tokenIndex95 := position, tokenIndex
+											if buffer[position] != rune(']') {
+												goto l95
+											}
+											position++
+											goto l92
+										l95:
+											position, tokenIndex = position95, tokenIndex95
+										}
+										if !matchDot() {
+											goto l92
+										}
+									}
+								l93:
+									goto l91
+								l92:
+									position, tokenIndex = position92, tokenIndex92
+								}
+								if buffer[position] != rune(']') {
+									goto l73
+								}
+								position++
+								add(rulePegText, position90)
+							}
+							{
+								add(ruleAction12, position)
+							}
+						}
+					l75:
+						add(ruleClass, position74)
+					}
+					goto l36
+				l73:
+					position, tokenIndex = position36, tokenIndex36
+					{
+						position98 := position
+						{
+							position99 := position
+							{
+								position100, tokenIndex100 := position, tokenIndex
+								if buffer[position] != rune('^') {
+									goto l101
+								}
+								position++
+								goto l100
+							l101:
+								position, tokenIndex = position100, tokenIndex100
+								if buffer[position] != rune('$') {
+									goto l102
+								}
+								position++
+								goto l100
+							l102:
+								position, tokenIndex = position100, tokenIndex100
+								if buffer[position] != rune('\\') {
+									goto l97
+								}
+								position++
+								{
+									switch buffer[position] {
+									case 'B':
+										if buffer[position] != rune('B') {
+											goto l97
+										}
+										position++
+										break
+									default:
+										if buffer[position] != rune('b') {
+											goto l97
+										}
+										position++
+										break
+									}
+								}
+
+							}
+						l100:
+							add(rulePegText, position99)
+						}
+						{
+							add(ruleAction13, position)
+						}
+						add(ruleAnchor, position98)
+					}
+					goto l36
+				l97:
+					position, tokenIndex = position36, tokenIndex36
+					{
+						position106 := position
+						{
+							position107, tokenIndex107 := position, tokenIndex
+							if buffer[position] != rune('(') {
+								goto l108
+							}
+							position++
+							if buffer[position] != rune('?') {
+								goto l108
+							}
+							position++
+							if buffer[position] != rune('=') {
+								goto l108
+							}
+							position++
+							if !_rules[ruleRegex]() {
+								goto l108
+							}
+							if buffer[position] != rune(')') {
+								goto l108
+							}
+							position++
+							{
+								add(ruleAction14, position)
+							}
+							goto l107
+						l108:
+							position, tokenIndex = position107, tokenIndex107
+							if buffer[position] != rune('(') {
+								goto l105
+							}
+							position++
+							if buffer[position] != rune('?') {
+								goto l105
+							}
+							position++
+							if buffer[position] != rune('!') {
+								goto l105
+							}
+							position++
+							if !_rules[ruleRegex]() {
+								goto l105
+							}
+							if buffer[position] != rune(')') {
+								goto l105
+							}
+							position++
+							{
+								add(ruleAction15, position)
+							}
+						}
+					l107:
+						add(ruleLookahead, position106)
+					}
+					goto l36
+				l105:
+					position, tokenIndex = position36, tokenIndex36
 					if buffer[position] != rune('(') {
-						goto l28
+						goto l34
 					}
 					position++
 					if !_rules[ruleRegex]() {
-						goto l28
+						goto l34
 					}
 					if buffer[position] != rune(')') {
-						goto l28
+						goto l34
 					}
 					position++
 				}
-			l30:
-				add(ruleFactor, position29)
+			l36:
+				add(ruleFactor, position35)
 			}
 			return true
-		l28:
-			position, tokenIndex = position28, tokenIndex28
+		l34:
+			position, tokenIndex = position34, tokenIndex34
 			return false
 		},
-		/* 8 Char <- <((<(!((&('.') '.') | (&(')') ')') | (&('(') '(') | (&('\\') '\\') | (&('!') '!') | (&('*') '*') | (&('+') '+')) .)> Action4) / ('\\' <((&('.') '.') | (&(')') ')') | (&('(') '(') | (&('\\') '\\') | (&('!') '!') | (&('*') '*') | (&('+') '+'))> Action5) / ('.' Action6))> */
+		/* 9 Ref <- <(<('{' ((&('_') '_') | (&('A' .. 'Z') [A-Z]) | (&('a' .. 'z') [a-z])) ((&('_') '_') | (&('0' .. '9') [0-9]) | (&('A' .. 'Z') [A-Z]) | (&('a' .. 'z') [a-z]))* '}')> Action5)> */
+		nil,
+		/* 10 Char <- <((<(!((&('$') '$') | (&('^') '^') | (&('[') '[') | (&('.') '.') | (&(')') ')') | (&('(') '(') | (&('\\') '\\') | (&('!') '!') | (&('*') '*') | (&('&') '&') | (&('+') '+')) .)> Action6) / ('\\' <((&('{') '{') | (&('$') '$') | (&('^') '^') | (&(']') ']') | (&('[') '[') | (&('?') '?') | (&('.') '.') | (&(')') ')') | (&('(') '(') | (&('\\') '\\') | (&('!') '!') | (&('*') '*') | (&('&') '&') | (&('+') '+'))> Action7) / (<('\\' Escape)> Action8) / ('.' Action9))> */
+		nil,
+		/* 11 Escape <- <(((&('v') 'v') | (&('t') 't') | (&('r') 'r') | (&('n') 'n') | (&('f') 'f') | (&('a') 'a')) / ([0-7] [0-7]? [0-7]?) / ('x' Hex Hex) / (('u' '{') Hex+ '}'))> */
+		nil,
+		/* 12 Hex <- <((&('A' .. 'F') [A-F]) | (&('a' .. 'f') [a-f]) | (&('0' .. '9') [0-9]))> */
+		func() bool {
+			position114, tokenIndex114 := position, tokenIndex
+			{
+				position115 := position
+				{
+					switch buffer[position] {
+					case 'A', 'B', 'C', 'D', 'E', 'F':
+						if c := buffer[position]; c < rune('A') || c > rune('F') {
+							goto l114
+						}
+						position++
+						break
+					case 'a', 'b', 'c', 'd', 'e', 'f':
+						if c := buffer[position]; c < rune('a') || c > rune('f') {
+							goto l114
+						}
+						position++
+						break
+					default:
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l114
+						}
+						position++
+						break
+					}
+				}
+
+				add(ruleHex, position115)
+			}
+			return true
+		l114:
+			position, tokenIndex = position114, tokenIndex114
+			return false
+		},
+		/* 13 Class <- <((<('\\' ((&('W') 'W') | (&('S') 'S') | (&('D') 'D') | (&('w') 'w') | (&('s') 's') | (&('d') 'd')))> Action10) / (<('\\' ((&('P') 'P') | (&('p') 'p')) (('{' (!'}' .)* '}') / (!'{' .)))> Action11) / (<('[' (('\\' .) / (!']' .))* ']')> Action12))> */
+		nil,
+		/* 14 Anchor <- <(<('^' / '$' / ('\\' ((&('B') 'B') | (&('b') 'b'))))> Action13)> */
+		nil,
+		/* 15 Lookahead <- <((('(' '?' '=') Regex ')' Action14) / (('(' '?' '!') Regex ')' Action15))> */
+		nil,
+		/* 17 Action0 <- <{ p.union() }> */
+		nil,
+		/* 18 Action1 <- <{ p.intersection() }> */
+		nil,
+		/* 19 Action2 <- <{ p.concat() }> */
+		nil,
+		/* 20 Action3 <- <{ p.comp() }> */
+		nil,
+		/* 21 Action4 <- <{ p.kleene() }> */
+		nil,
+		nil,
+		/* 23 Action5 <- <{ p.ref(text, begin) }> */
+		nil,
+		/* 24 Action6 <- <{ p.char(firstRune(text), begin) }> */
+		nil,
+		/* 25 Action7 <- <{ p.char(lastRune(text), begin) }> */
 		nil,
-		/* 10 Action0 <- <{ p.union() }> */
+		/* 26 Action8 <- <{ p.escape(text, begin) }> */
 		nil,
-		/* 11 Action1 <- <{ p.concat() }> */
+		/* 27 Action9 <- <{ p.any() }> */
 		nil,
-		/* 12 Action2 <- <{ p.comp() }> */
+		/* 28 Action10 <- <{ p.class(text, begin) }> */
 		nil,
-		/* 13 Action3 <- <{ p.kleene() }> */
+		/* 29 Action11 <- <{ p.class(text, begin) }> */
 		nil,
+		/* 30 Action12 <- <{ p.class(text, begin) }> */
 		nil,
-		/* 15 Action4 <- <{ p.char(firstRune(text)) }> */
+		/* 31 Action13 <- <{ p.anchor(text) }> */
 		nil,
-		/* 16 Action5 <- <{ p.char(lastRune(text)) }> */
+		/* 32 Action14 <- <{ p.lookahead(false) }> */
 		nil,
-		/* 17 Action6 <- <{ p.any() }> */
+		/* 33 Action15 <- <{ p.lookahead(true) }> */
 		nil,
 	}
 	p.rules = _rules
@@ -0,0 +1,82 @@
+package dr
+
+import "bytes"
+
+// Operator precedence, from loosest to tightest binding, as defined by
+// the grammar in peg.peg.
+const (
+	precUnion = iota
+	precIntersection
+	precConcat
+	precUnary
+	precFactor
+)
+
+func precedence(r Regex) int {
+	switch r := r.(type) {
+	case *union:
+		return precUnion
+	case *intersection:
+		return precIntersection
+	case *concat:
+		return precConcat
+	case *comp, *kleene:
+		return precUnary
+	case *empty, *epsilon, *char, *any, *class, *anchor, *lookahead:
+		return precFactor
+	case *located:
+		return precedence(r.r)
+	default:
+		// Nothing is known about how other regexes print,
+		// so always parenthesize them.
+		return precUnion
+	}
+}
+
+// printRegex prints r using as few parentheses as possible, such that
+// parsing the result gives back the same structure. Union, intersection
+// and concatenation are parsed as right associative, so a left operand
+// of the same kind needs parentheses.
+//
+// There is no syntax for ∅ and ε, so regexes containing them don't
+// round trip.
+func printRegex(r Regex) string {
+	var buf bytes.Buffer
+	writeRegex(&buf, r)
+	return buf.String()
+}
+
+func writeRegex(buf *bytes.Buffer, r Regex) {
+	switch r := r.(type) {
+	case *union:
+		writeOperand(buf, r.l, precIntersection)
+		buf.WriteByte('+')
+		writeOperand(buf, r.r, precUnion)
+	case *intersection:
+		writeOperand(buf, r.l, precConcat)
+		buf.WriteByte('&')
+		writeOperand(buf, r.r, precIntersection)
+	case *concat:
+		writeOperand(buf, r.l, precUnary)
+		writeOperand(buf, r.r, precConcat)
+	case *comp:
+		buf.WriteByte('!')
+		writeOperand(buf, r.r, precFactor)
+	case *kleene:
+		writeOperand(buf, r.r, precFactor)
+		buf.WriteByte('*')
+	default:
+		buf.WriteString(r.String())
+	}
+}
+
+// writeOperand writes r, parenthesized if it binds looser than prec.
+func writeOperand(buf *bytes.Buffer, r Regex, prec int) {
+	if precedence(r) < prec {
+		buf.WriteByte('(')
+		writeRegex(buf, r)
+		buf.WriteByte(')')
+		return
+	}
+	writeRegex(buf, r)
+}
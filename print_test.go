@@ -0,0 +1,98 @@
+package dr
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestStringMinimal(t *testing.T) {
+	tests := []struct {
+		r    Regex
+		want string
+	}{
+		{MustParse("aaa+bbb"), "aaa+bbb"},
+		{MustParse("!(a)b*(cd)*e+f"), "!ab*(cd)*e+f"},
+		{MustParse(`\+\++\*(\!\\)`), `\+\++\*\!\\`},
+		{MustParse("((a))*"), "a*"},
+		{NewConcat(NewUnion(NewChar('a'), NewChar('b')), NewChar('c')), "(a+b)c"},
+		{NewUnion(NewUnion(NewChar('a'), NewChar('b')), NewChar('c')), "(a+b)+c"},
+		{NewConcat(NewConcat(NewChar('a'), NewChar('b')), NewChar('c')), "(ab)c"},
+		{NewKleene(NewKleene(NewChar('a'))), "(a*)*"},
+		{NewComp(NewConcat(NewChar('a'), NewAny())), "!(a.)"},
+		{NewKleene(NewComp(NewChar('a'))), "(!a)*"},
+		{NewConcat(NewUnion(NewConcat(NewChar('?'), NewComp(NewChar('a'))), NewChar('b')), NewChar('c')), `(\?!a+b)c`},
+		{NewKleene(NewConcat(NewChar('?'), NewConcat(NewChar('='), NewChar('a')))), `(\?=a)*`},
+		{MustParse("a?b"), `a\?b`},
+		{MustParse("ab&c+d&e*"), "ab&c+d&e*"},
+		{NewIntersection(NewIntersection(NewChar('a'), NewChar('b')), NewChar('c')), "(a&b)&c"},
+		{NewIntersection(NewUnion(NewChar('a'), NewChar('b')), NewChar('c')), "(a+b)&c"},
+		{NewConcat(NewIntersection(NewChar('a'), NewChar('b')), NewChar('c')), "(a&b)c"},
+		{MustParse("[\n\x01]"), `[\n\x01]`},
+	}
+
+	for _, test := range tests {
+		if got := test.r.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+var randomChars = []rune("ab.+&*!?=\\()[]^${}é∅ε\n\x00\u00ad\U0001F600")
+
+var randomAnchors = []Regex{NewStart(), NewEnd(), NewWordBoundary(false), NewWordBoundary(true)}
+
+// randomRegex generates a random regex made of the nodes that have
+// syntax in the grammar.
+func randomRegex(rnd *rand.Rand, depth int) Regex {
+	n := 10
+	if depth == 0 {
+		n = 4
+	}
+
+	switch rnd.Intn(n) {
+	case 0:
+		return NewChar(randomChars[rnd.Intn(len(randomChars))])
+	case 1:
+		return NewAny()
+	case 2:
+		return randomClass(rnd)
+	case 3:
+		return randomAnchors[rnd.Intn(len(randomAnchors))]
+	case 4:
+		return NewUnion(randomRegex(rnd, depth-1), randomRegex(rnd, depth-1))
+	case 5:
+		return NewConcat(randomRegex(rnd, depth-1), randomRegex(rnd, depth-1))
+	case 6:
+		return NewIntersection(randomRegex(rnd, depth-1), randomRegex(rnd, depth-1))
+	case 7:
+		return NewComp(randomRegex(rnd, depth-1))
+	case 8:
+		return NewLookahead(randomRegex(rnd, depth-1), rnd.Intn(2) == 0)
+	default:
+		return NewKleene(randomRegex(rnd, depth-1))
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 5000; i++ {
+		r := randomRegex(rnd, 1+rnd.Intn(6))
+		s := r.String()
+		if i := strings.IndexFunc(s, func(c rune) bool { return !unicode.IsPrint(c) }); i >= 0 {
+			t.Errorf("%q has a non-printable character at %v", s, i)
+		}
+
+		parsed, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %v", s, err)
+			continue
+		}
+
+		if got, want := termKey(parsed), termKey(r); got != want {
+			t.Errorf("Parse(%q) = %v, structure %v, want %v", s, parsed, got, want)
+		}
+	}
+}
@@ -14,14 +14,28 @@ type Regex interface {
 // Match returns true if the string matches the regex.
 // This is sugar for calling Derivative on the regex
 // repeatedly, then checking if the current state
-// accepts epsilon.
+// accepts epsilon. Regexes with anchors are first
+// wrapped to track the location in the string.
 func Match(r Regex, s string) bool {
+	r = locate(r)
 	for _, c := range s {
 		r = r.Derivative(c)
 	}
 	return r.Accepting()
 }
 
+// Derive returns the derivative of r with respect to each character of s
+// in turn, simplified so that repeated derivatives stay small. Like Match,
+// it tracks the location for anchors, so Match(r, s) is the same as
+// Derive(r, s).Accepting(), and the result can be derived further.
+func Derive(r Regex, s string) Regex {
+	r = simplify(locate(r))
+	for _, c := range s {
+		r = simplify(r.Derivative(c))
+	}
+	return r
+}
+
 type empty struct{}
 
 // NewEmpty creates a regex that accepts nothing.
@@ -75,19 +89,26 @@ func NewChar(r rune) Regex {
 
 var escaped = map[rune]bool{
 	'!':  true,
+	'$':  true,
+	'&':  true,
 	'(':  true,
 	')':  true,
 	'*':  true,
 	'+':  true,
 	'\\': true,
 	'.':  true,
+	'?':  true,
+	'[':  true,
+	']':  true,
+	'^':  true,
+	'{':  true,
 }
 
 func (c *char) String() string {
 	if escaped[c.r] {
 		return fmt.Sprintf("\\%c", c.r)
 	}
-	return fmt.Sprintf("%c", c.r)
+	return escapeRune(c.r)
 }
 
 // Derivative returns Epsilon if r is Char's value,
@@ -127,8 +148,10 @@ func (*any) Accepting() bool {
 
 // Union accepts the union of two regexes.
 type union struct {
-	l Regex
-	r Regex
+	l        Regex
+	r        Regex
+	nullable bool
+	memo     *derivCache
 }
 
 // NewUnion creates a regex that accepts the union of two regexes,
@@ -139,31 +162,78 @@ func NewUnion(l, r Regex) Regex {
 		return r
 	default:
 		return &union{
-			l: l,
-			r: r,
+			l:        l,
+			r:        r,
+			nullable: l.Accepting() || r.Accepting(),
 		}
 	}
 }
 
 func (u *union) String() string {
-	return fmt.Sprintf("(%v)+(%v)", u.l, u.r)
+	return printRegex(u)
 }
 
 // Derivative returns the union of the derivatives
 // of this union.
 func (u *union) Derivative(r rune) Regex {
-	return NewUnion(u.l.Derivative(r), u.r.Derivative(r))
+	if d, ok := u.memo.lookup(r); ok {
+		return d
+	}
+	return u.memo.store(r, NewUnion(u.l.Derivative(r), u.r.Derivative(r)))
 }
 
 // Accepting returns true if either of the elements
-// in the union are accepting.
+// in the union are accepting, as found by NewUnion.
 func (u *union) Accepting() bool {
-	return u.l.Accepting() || u.r.Accepting()
+	return u.nullable
+}
+
+// Intersection accepts the intersection of two regexes.
+type intersection struct {
+	l        Regex
+	r        Regex
+	nullable bool
+	memo     *derivCache
+}
+
+// NewIntersection creates a regex that accepts the strings accepted by
+// both of two regexes, taking into consideration the simplifiying
+// equations.
+func NewIntersection(l, r Regex) Regex {
+	if isEmpty(l) || isEmpty(r) {
+		return NewEmpty()
+	}
+	return &intersection{
+		l:        l,
+		r:        r,
+		nullable: l.Accepting() && r.Accepting(),
+	}
+}
+
+func (i *intersection) String() string {
+	return printRegex(i)
+}
+
+// Derivative returns the intersection of the derivatives
+// of this intersection.
+func (i *intersection) Derivative(r rune) Regex {
+	if d, ok := i.memo.lookup(r); ok {
+		return d
+	}
+	return i.memo.store(r, NewIntersection(i.l.Derivative(r), i.r.Derivative(r)))
+}
+
+// Accepting returns true if both of the elements
+// in the intersection are accepting, as found by NewIntersection.
+func (i *intersection) Accepting() bool {
+	return i.nullable
 }
 
 type concat struct {
-	l Regex
-	r Regex
+	l        Regex
+	r        Regex
+	nullable bool
+	memo     *derivCache
 }
 
 // NewConcat creates a regex that accepts the concatenation of two regexes,
@@ -176,67 +246,74 @@ func NewConcat(l, r Regex) Regex {
 		return r
 	default:
 		return &concat{
-			l: l,
-			r: r,
+			l:        l,
+			r:        r,
+			nullable: l.Accepting() && r.Accepting(),
 		}
 	}
 }
 
 func (c *concat) String() string {
-	return fmt.Sprintf("%v%v", c.l, c.r)
+	return printRegex(c)
 }
 
-// Derivative returns the union of the concatenation of
-// the derivative of L and R, and the derivative of R if
-// if L accepts epsilon, otherwise Empty.
+// Derivative returns the concatenation of the derivative
+// of L and R, in union with the derivative of R if L
+// accepts epsilon.
 func (c *concat) Derivative(r rune) Regex {
-	var right Regex
-	if c.l.Accepting() {
-		right = c.r.Derivative(r)
-	} else {
-		right = NewEmpty()
+	if d, ok := c.memo.lookup(r); ok {
+		return d
 	}
 
-	return NewUnion(
-		NewConcat(c.l.Derivative(r), c.r),
-		right,
-	)
+	d := NewConcat(c.l.Derivative(r), c.r)
+	if c.l.Accepting() {
+		d = NewUnion(d, c.r.Derivative(r))
+	}
+	return c.memo.store(r, d)
 }
 
-// Accepting returns true if both elements are accepting.
+// Accepting returns true if both elements are accepting,
+// as found by NewConcat.
 func (c *concat) Accepting() bool {
-	return c.l.Accepting() && c.r.Accepting()
+	return c.nullable
 }
 
 type comp struct {
-	r Regex
+	r        Regex
+	nullable bool
+	memo     *derivCache
 }
 
 // NewComp creates a regex that accepts the complement of a regex.
 func NewComp(r Regex) Regex {
 	return &comp{
-		r: r,
+		r:        r,
+		nullable: !r.Accepting(),
 	}
 }
 
 func (c *comp) String() string {
-	return fmt.Sprintf("!(%v)", c.r)
+	return printRegex(c)
 }
 
 // Derivative returns the complement of the derivative of the
 // complemented regex.
 func (c *comp) Derivative(r rune) Regex {
-	return NewComp(c.r.Derivative(r))
+	if d, ok := c.memo.lookup(r); ok {
+		return d
+	}
+	return c.memo.store(r, NewComp(c.r.Derivative(r)))
 }
 
 // Accepting returns true if the complemented regex
-// does not accepting.
+// does not accepting, as found by NewComp.
 func (c *comp) Accepting() bool {
-	return !c.r.Accepting()
+	return c.nullable
 }
 
 type kleene struct {
-	r Regex
+	r    Regex
+	memo *derivCache
 }
 
 // NewKleene creates a regex that accepts the Kleene star of a regex.
@@ -247,13 +324,16 @@ func NewKleene(r Regex) Regex {
 }
 
 func (k *kleene) String() string {
-	return fmt.Sprintf("(%v)*", k.r)
+	return printRegex(k)
 }
 
 // Derivative returns the concatenation of the derivative
 // of the Kleene star'd regex and the regex.
 func (k *kleene) Derivative(r rune) Regex {
-	return NewConcat(k.r.Derivative(r), k)
+	if d, ok := k.memo.lookup(r); ok {
+		return d
+	}
+	return k.memo.store(r, NewConcat(k.r.Derivative(r), k))
 }
 
 // Accepting returns true.
@@ -267,6 +347,7 @@ var (
 	_ Regex = &any{}
 	_ Regex = &char{}
 	_ Regex = &union{}
+	_ Regex = &intersection{}
 	_ Regex = &comp{}
 	_ Regex = &kleene{}
 )
@@ -1,6 +1,71 @@
 package dr
 
-import "testing"
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestIntersection(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		fails   []string
+	}{
+		{"a*&(aa)*", []string{"", "aa", "aaaa"}, []string{"a", "aaa"}},
+		{".*a.*&.*b.*", []string{"ab", "ba", "xaybz"}, []string{"", "a", "bb"}},
+		{"ab+a.&.b", []string{"ab"}, []string{"aa", "bb"}},
+		{`\w*&!(.*\d.*)`, []string{"", "abc"}, []string{"a1", "-"}},
+		{"a&!a", nil, []string{"", "a"}},
+	}
+
+	for _, test := range tests {
+		r := MustParse(test.pattern)
+		d := MustCompile(r, 0)
+		for _, s := range test.matches {
+			if !Match(r, s) || !d.Match(s) {
+				t.Errorf("%q does not match %q", test.pattern, s)
+			}
+		}
+		for _, s := range test.fails {
+			if Match(r, s) || d.Match(s) {
+				t.Errorf("%q matches %q", test.pattern, s)
+			}
+		}
+	}
+}
+
+// nullable is the recursive definition of Accepting, which the nodes
+// compute once when they are created.
+func nullable(r Regex) bool {
+	switch r := r.(type) {
+	case *union:
+		return nullable(r.l) || nullable(r.r)
+	case *intersection:
+		return nullable(r.l) && nullable(r.r)
+	case *concat:
+		return nullable(r.l) && nullable(r.r)
+	case *comp:
+		return !nullable(r.r)
+	default:
+		return r.Accepting()
+	}
+}
+
+func TestAccepting(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		r := randomRegex(rnd, 1+rnd.Intn(6))
+		for _, c := range "ab" {
+			if got, want := r.Accepting(), nullable(r); got != want {
+				t.Errorf("%v.Accepting() = %v, want %v", r, got, want)
+			}
+			r = r.Derivative(c)
+		}
+	}
+}
 
 func BenchmarkParseSimple(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -31,3 +96,259 @@ func BenchmarkMatchComplex(b *testing.B) {
 		Match(r, "abccccccccc")
 	}
 }
+
+func BenchmarkMatchComplexMemo(b *testing.B) {
+	r := Memoize(MustParse("!(a+b*(asd(!d)))+(def)*"))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Match(r, "abccccccccc")
+	}
+}
+
+func BenchmarkMatchSimpleNFA(b *testing.B) {
+	n := NewNFA(MustParse("abc*+aad"))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		n.Match("abccccccccc")
+	}
+}
+
+func BenchmarkMatchComplexNFA(b *testing.B) {
+	n := NewNFA(MustParse("!(a+b*(asd(!d)))+(def)*"))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		n.Match("abccccccccc")
+	}
+}
+
+func BenchmarkMatchUnionStar(b *testing.B) {
+	r := MustParse("(a+ab)*(b+ba)*")
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Match(r, "abababababababab")
+	}
+}
+
+func BenchmarkMatchUnionStarMemo(b *testing.B) {
+	r := Memoize(MustParse("(a+ab)*(b+ba)*"))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Match(r, "abababababababab")
+	}
+}
+
+func BenchmarkMatchUnionStarNFA(b *testing.B) {
+	n := NewNFA(MustParse("(a+ab)*(b+ba)*"))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		n.Match("abababababababab")
+	}
+}
+
+var growthPatterns = []struct {
+	name    string
+	pattern string
+}{
+	{"Complex", "!(a+b*(asd(!d)))+(def)*"},
+	{"UnionStar", "(a+ab)*(b+ba)*"},
+	{"Suffix", "(a+b)*a(a+b)(a+b)(a+b)"},
+	{"Intersection", ".*a.*&!(.*b.*)"},
+}
+
+// BenchmarkMatchGrowth matches each pattern against inputs of increasing
+// length, reporting the size of the final derivative, so the growth of
+// the terms can be compared with the growth of the time taken.
+func BenchmarkMatchGrowth(b *testing.B) {
+	for _, p := range growthPatterns {
+		r := MustParse(p.pattern)
+		for _, n := range []int{16, 64, 256} {
+			s := strings.Repeat("ab", n/2)
+			b.Run(fmt.Sprintf("%v/%v", p.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					Match(r, s)
+				}
+
+				trace := Trace(r, s)
+				b.ReportMetric(float64(trace[len(trace)-1]), "size")
+			})
+		}
+	}
+}
+
+// The parallel benchmarks match from every goroutine at once, comparing
+// the cached matchers, which share their caches, with uncached Match.
+
+func BenchmarkMatchComplexParallel(b *testing.B) {
+	r := MustParse("!(a+b*(asd(!d)))+(def)*")
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Match(r, "abccccccccc")
+		}
+	})
+}
+
+func BenchmarkMatchComplexMemoParallel(b *testing.B) {
+	r := Memoize(MustParse("!(a+b*(asd(!d)))+(def)*"))
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Match(r, "abccccccccc")
+		}
+	})
+}
+
+func BenchmarkMatchComplexNFAParallel(b *testing.B) {
+	n := NewNFA(MustParse("!(a+b*(asd(!d)))+(def)*"))
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n.Match("abccccccccc")
+		}
+	})
+}
+
+func BenchmarkMatchComplexSetParallel(b *testing.B) {
+	set := NewSet([]Regex{MustParse("!(a+b*(asd(!d)))+(def)*")})
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			set.First("abccccccccc")
+		}
+	})
+}
+
+func BenchmarkMatchComplexDFAParallel(b *testing.B) {
+	d := MustCompile(MustParse("!(a+b*(asd(!d)))+(def)*"), 0)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			d.Match("abccccccccc")
+		}
+	})
+}
+
+// deepConcat returns the concatenation of n copies of r, nested to the
+// left if left is true, or to the right as the parser does.
+func deepConcat(r Regex, n int, left bool) Regex {
+	c := r
+	for i := 1; i < n; i++ {
+		if left {
+			c = NewConcat(c, r)
+		} else {
+			c = NewConcat(r, c)
+		}
+	}
+	return c
+}
+
+// BenchmarkMatchDeepConcat matches long concatenations of a. Nested to the
+// left, every step of Match checks whether each prefix of the term accepts,
+// which without cached nullability made each step quadratic.
+func BenchmarkMatchDeepConcat(b *testing.B) {
+	for _, test := range []struct {
+		name string
+		n    int
+		left bool
+	}{
+		{"Right/1000", 1000, false},
+		{"Left/100", 100, true},
+		{"Left/200", 200, true},
+	} {
+		r := deepConcat(NewChar('a'), test.n, test.left)
+		s := strings.Repeat("a", test.n)
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Match(r, s)
+			}
+		})
+	}
+}
+
+func BenchmarkAcceptingDeepConcat(b *testing.B) {
+	r := deepConcat(NewKleene(NewChar('a')), 1000, false)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Accepting()
+	}
+}
+
+var setPatterns = []string{
+	"/users/!(.*/.*)",
+	"/users/!(.*/.*)/posts",
+	"/users/!(.*/.*)/posts/!(.*/.*)",
+	"/static/.*",
+	"/api/v(1+2)/.*",
+	"/health",
+}
+
+func BenchmarkMatchEach(b *testing.B) {
+	var rs []Regex
+	for _, p := range setPatterns {
+		rs = append(rs, MustParse(p))
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, r := range rs {
+			Match(r, "/users/jake/posts/123")
+		}
+	}
+}
+
+func BenchmarkMatchEachMemo(b *testing.B) {
+	var rs []Regex
+	for _, p := range setPatterns {
+		rs = append(rs, Memoize(MustParse(p)))
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, r := range rs {
+			Match(r, "/users/jake/posts/123")
+		}
+	}
+}
+
+func BenchmarkMatchSet(b *testing.B) {
+	var rs []Regex
+	for _, p := range setPatterns {
+		rs = append(rs, MustParse(p))
+	}
+	set := NewSet(rs)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		set.Match("/users/jake/posts/123")
+	}
+}
+
+func BenchmarkMatchSimpleDFA(b *testing.B) {
+	d := MustCompile(MustParse("abc*+aad"), 0)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		d.Match("abccccccccc")
+	}
+}
+
+func BenchmarkMatchComplexDFA(b *testing.B) {
+	d := MustCompile(MustParse("!(a+b*(asd(!d)))+(def)*"), 0)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		d.Match("abccccccccc")
+	}
+}
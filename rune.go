@@ -1,5 +1,13 @@
 package dr
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 func firstRune(s string) rune {
 	var r rune
 	for _, c := range s {
@@ -16,3 +24,102 @@ func lastRune(s string) rune {
 	}
 	return r
 }
+
+// letterEscapes maps the letters of single character escapes to the
+// characters they represent.
+var letterEscapes = map[rune]rune{
+	'a': '\a',
+	'f': '\f',
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'v': '\v',
+}
+
+// unescape decodes the character escape at the start of s, which begins
+// with a backslash, returning the character and the escape's length in
+// bytes. The escapes are \a, \f, \n, \r, \t, \v, one to three octal
+// digits, \xHH and \u{H...}.
+func unescape(s string) (rune, int, *classError) {
+	invalid := func(n int) (rune, int, *classError) {
+		if n > len(s) {
+			n = len(s)
+		}
+		return 0, 0, &classError{0, fmt.Sprintf("invalid escape '%v'", s[:n])}
+	}
+
+	if len(s) < 2 || s[0] != '\\' {
+		return invalid(1)
+	}
+
+	c := rune(s[1])
+	if r, ok := letterEscapes[c]; ok {
+		return r, 2, nil
+	}
+
+	switch {
+	case '0' <= c && c <= '7':
+		n := 2
+		for n < 4 && n < len(s) && '0' <= s[n] && s[n] <= '7' {
+			n++
+		}
+		v, _ := strconv.ParseUint(s[1:n], 8, 32)
+		if v > 0xFF {
+			return 0, 0, &classError{0, fmt.Sprintf("octal escape '%v' is greater than \\377", s[:n])}
+		}
+		return rune(v), n, nil
+
+	case c == 'x':
+		if len(s) < 4 || !isHex(s[2]) || !isHex(s[3]) {
+			return invalid(4)
+		}
+		v, _ := strconv.ParseUint(s[2:4], 16, 32)
+		return rune(v), 4, nil
+
+	case c == 'u':
+		end := strings.IndexByte(s, '}')
+		if len(s) < 4 || s[2] != '{' || end < 4 {
+			return invalid(3)
+		}
+		for i := 3; i < end; i++ {
+			if !isHex(s[i]) {
+				return invalid(end + 1)
+			}
+		}
+		v, err := strconv.ParseUint(s[3:end], 16, 32)
+		if err != nil || v > unicode.MaxRune {
+			return 0, 0, &classError{0, fmt.Sprintf("escape '%v' is not a Unicode code point", s[:end+1])}
+		}
+		if 0xD800 <= v && v <= 0xDFFF {
+			return 0, 0, &classError{0, fmt.Sprintf("escape '%v' is a surrogate, not a character", s[:end+1])}
+		}
+		return rune(v), end + 1, nil
+	}
+
+	_, size := utf8.DecodeRuneInString(s[1:])
+	return invalid(1 + size)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// escapeRune returns r as it should be written in a pattern, outside of
+// any metacharacter escaping. Characters which aren't printable are
+// written as escapes, so that the result is safe to log and re-parse.
+func escapeRune(r rune) string {
+	if unicode.IsPrint(r) {
+		return string(r)
+	}
+
+	for letter, c := range letterEscapes {
+		if c == r {
+			return `\` + string(letter)
+		}
+	}
+
+	if r < 0x100 {
+		return fmt.Sprintf(`\x%02X`, r)
+	}
+	return fmt.Sprintf(`\u{%X}`, r)
+}
@@ -0,0 +1,59 @@
+package dr
+
+import "testing"
+
+func TestEscapes(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   string
+	}{
+		{`\n`, "\n"},
+		{`\a\f\r\t\v`, "\a\f\r\t\v"},
+		{`\x41\x7e`, "A~"},
+		{`\u{e9}\u{1F600}`, "é😀"},
+		{`\0`, "\x00"},
+		{`\101\1010`, "AA0"},
+		{`\377`, "ÿ"},
+		{`[\x00-\x1F]*`, "\x01\x02\n"},
+		{`[^\n]*\n`, "line\n"},
+	}
+
+	for _, test := range tests {
+		r, err := Parse(test.pattern)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %v", test.pattern, err)
+			continue
+		}
+		if !Match(r, test.match) {
+			t.Errorf("%q does not match %q", test.pattern, test.match)
+		}
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	tests := []struct {
+		r    Regex
+		want string
+	}{
+		{NewChar('\n'), `\n`},
+		{NewChar('\x00'), `\x00`},
+		{NewChar('\u00ad'), `\xAD`},
+		{NewChar('\u200b'), `\u{200B}`},
+		{NewChar('é'), "é"},
+		{NewConcat(NewChar('\t'), NewChar(' ')), `\t `},
+	}
+
+	for _, test := range tests {
+		if got := test.r.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+		r, err := Parse(test.want)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %v", test.want, err)
+			continue
+		}
+		if termKey(r) != termKey(test.r) {
+			t.Errorf("Parse(%q) = %v, want %v", test.want, r, test.r)
+		}
+	}
+}
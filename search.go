@@ -0,0 +1,99 @@
+package dr
+
+import "unicode/utf8"
+
+// FindIndex returns the start and end byte offsets of the leftmost
+// longest substring of s matched by r, or nil if there is none. Anchors
+// see the whole of s, so ^ only matches at its start.
+func FindIndex(r Regex, s string) []int {
+	m := FindAllIndex(r, s, 1)
+	if m == nil {
+		return nil
+	}
+	return m[0]
+}
+
+// FindAllIndex returns the byte offsets of successive non-overlapping
+// leftmost longest matches of r in s, as FindIndex. An empty match
+// directly after another match is ignored. If n >= 0, at most n matches
+// are returned.
+func FindAllIndex(r Regex, s string, n int) [][]int {
+	if l, ok := r.(*located); ok {
+		r = l.r
+	}
+	anchored := hasAssertions(r)
+	r = simplify(r)
+
+	var matches [][]int
+	prevEnd := -1
+	for i := 0; i <= len(s) && (n < 0 || len(matches) < n); {
+		start := r
+		if anchored {
+			prev := sideEdge
+			if i > 0 {
+				c, _ := utf8.DecodeLastRuneInString(s[:i])
+				prev = sideOf(c)
+			}
+			start = &located{r: r, prev: prev}
+		}
+
+		if end := longestMatch(start, s, i); end >= 0 && !(end == i && i == prevEnd) {
+			matches = append(matches, []int{i, end})
+			prevEnd = end
+			if end > i {
+				i = end
+				continue
+			}
+		}
+
+		if i == len(s) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return matches
+}
+
+// longestMatch returns the end of the longest match of r starting at
+// byte offset i of s, or -1 if there is none.
+func longestMatch(r Regex, s string, i int) int {
+	end := -1
+	for {
+		if acceptsAt(r, s, i) {
+			end = i
+		}
+		if i == len(s) {
+			return end
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		r = simplify(r.Derivative(c))
+		if isEmpty(r) {
+			return end
+		}
+	}
+}
+
+// acceptsAt returns true if r accepts the empty string at byte offset i of
+// s, which for anchors and lookaheads depends on what follows.
+func acceptsAt(r Regex, s string, i int) bool {
+	l, ok := r.(*located)
+	if !ok {
+		return r.Accepting()
+	}
+	return nullableIn(l.r, l.prev, stringInput(s), i)
+}
+
+// stringInput is a string read at byte offsets.
+type stringInput string
+
+func (s stringInput) at(i int) (rune, int, bool) {
+	if i >= len(s) {
+		return 0, i, false
+	}
+	c, size := utf8.DecodeRuneInString(string(s[i:]))
+	return c, i + size, true
+}
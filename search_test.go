@@ -0,0 +1,42 @@
+package dr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindAllIndex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    [][]int
+	}{
+		{"ab*", "xabbyab", [][]int{{1, 4}, {5, 7}}},
+		{"b*", "abba", [][]int{{0, 0}, {1, 3}, {4, 4}}},
+		{"a+ab", "aab", [][]int{{0, 1}, {1, 3}}},
+		{"x", "abc", nil},
+		{"^a", "aaa", [][]int{{0, 1}}},
+		{"a$", "aaa", [][]int{{2, 3}}},
+		{`\bfoo\b`, "foo food (foo)", [][]int{{0, 3}, {10, 13}}},
+		{"é.", "aéb", [][]int{{1, 4}}},
+		{".*a.*&.*b.*", "xaby", [][]int{{0, 4}}},
+		{"a(?=b)", "ab", [][]int{{0, 1}}},
+		{"foo(?!bar)", "foobar foobaz", [][]int{{7, 10}}},
+		{`\w\w*(?=,)`, "ab,cd ef,", [][]int{{0, 2}, {6, 8}}},
+	}
+
+	for _, test := range tests {
+		r := MustParse(test.pattern)
+		if got := FindAllIndex(r, test.s, -1); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FindAllIndex(%q, %q) = %v, want %v", test.pattern, test.s, got, test.want)
+		}
+
+		var first []int
+		if len(test.want) != 0 {
+			first = test.want[0]
+		}
+		if got := FindIndex(r, test.s); !reflect.DeepEqual(got, first) {
+			t.Errorf("FindIndex(%q, %q) = %v, want %v", test.pattern, test.s, got, first)
+		}
+	}
+}
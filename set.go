@@ -0,0 +1,190 @@
+package dr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSetStates is the number of states a Set caches before it stops
+// caching new ones.
+const DefaultSetStates = 10000
+
+// Set matches a string against many regexes at once. It advances every
+// regex in a single pass over the input, lazily building a combined DFA
+// whose states are tuples of derivatives, each carrying the set of
+// regexes that accept at that point.
+//
+// A Set caches at most DefaultSetStates states; past that point, states
+// are computed as they are reached, without caching. It is safe for
+// concurrent use, and once a transition is cached, following it takes no
+// locks.
+type Set struct {
+	n     int
+	alpha alphabet
+	start [sideOther + 1]*setState // by the kind of character before
+
+	mu     sync.Mutex // guards states
+	states map[string]*setState
+	max    int
+}
+
+type setState struct {
+	terms      []Regex
+	accept     []uint64 // at the end of the input
+	contextual bool     // whether any term has anchors or lookaheads
+	dead       bool
+	cached     bool
+	next       []atomic.Value // *setState by class, once known
+}
+
+// NewSet creates a Set from the given regexes. Indices returned by the
+// Set's methods refer to positions in rs.
+func NewSet(rs []Regex) *Set {
+	s := &Set{
+		n:      len(rs),
+		alpha:  newAlphabet(rs...),
+		states: make(map[string]*setState),
+		max:    DefaultSetStates,
+	}
+
+	for prev := range s.start {
+		terms := make([]Regex, len(rs))
+		for i, r := range rs {
+			terms[i] = simplify(locateAt(r, side(prev)))
+		}
+		s.start[prev] = s.state(terms)
+	}
+	return s
+}
+
+// Len returns the number of regexes in the Set.
+func (s *Set) Len() int {
+	return s.n
+}
+
+func (s *Set) state(terms []Regex) *setState {
+	var k []byte
+	for _, t := range terms {
+		k = append(k, termKey(t)...)
+		k = append(k, 0)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.states[string(k)]; ok {
+		return st
+	}
+
+	st := &setState{
+		terms:  terms,
+		accept: make([]uint64, (len(terms)+63)/64),
+		dead:   true,
+	}
+	for i, t := range terms {
+		if t.Accepting() {
+			st.accept[i/64] |= 1 << uint(i%64)
+		}
+		if !isEmpty(t) {
+			st.dead = false
+		}
+		if _, ok := t.(*located); ok {
+			st.contextual = true
+		}
+	}
+
+	if len(s.states) < s.max {
+		st.cached = true
+		st.next = make([]atomic.Value, s.alpha.size())
+		s.states[string(k)] = st
+	}
+	return st
+}
+
+func (s *Set) step(st *setState, c rune) *setState {
+	class := s.alpha.class(c)
+	if st.cached {
+		if next, ok := st.next[class].Load().(*setState); ok {
+			return next
+		}
+	}
+
+	terms := make([]Regex, len(st.terms))
+	for i, t := range st.terms {
+		terms[i] = simplify(t.Derivative(c))
+	}
+
+	// Another goroutine may store the same transition first, but it
+	// finds the same state, so it doesn't matter which store wins.
+	// Transitions to uncached states aren't kept, so they can be freed.
+	next := s.state(terms)
+	if st.cached && next.cached {
+		st.next[class].Store(next)
+	}
+	return next
+}
+
+func (s *Set) run(str string) *setState {
+	st := s.start[sideEdge]
+	for _, c := range str {
+		if st.dead {
+			break
+		}
+		st = s.step(st, c)
+	}
+	return st
+}
+
+// Match returns the indices of every regex in the Set that matches
+// the string, in increasing order.
+func (s *Set) Match(str string) []int {
+	st := s.run(str)
+
+	var matches []int
+	for i, word := range st.accept {
+		for j := 0; j < 64 && word != 0; j++ {
+			if word&1 != 0 {
+				matches = append(matches, i*64+j)
+			}
+			word >>= 1
+		}
+	}
+	return matches
+}
+
+// First returns the index of the first regex in the Set that matches
+// the string, or -1 if none do.
+func (s *Set) First(str string) int {
+	return s.run(str).first()
+}
+
+// firstIn is like first, but for the terms at position i of in rather
+// than at the end of the input, which matters for anchors and lookaheads.
+func (st *setState) firstIn(in input, i int) int {
+	if !st.contextual {
+		return st.first()
+	}
+
+	for j, t := range st.terms {
+		if l, ok := t.(*located); ok {
+			if nullableIn(l.r, l.prev, in, i) {
+				return j
+			}
+		} else if st.accept[j/64]&(1<<uint(j%64)) != 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// first returns the lowest accepting index, or -1.
+func (st *setState) first() int {
+	for i, word := range st.accept {
+		for j := 0; j < 64; j++ {
+			if word&(1<<uint(j)) != 0 {
+				return i*64 + j
+			}
+		}
+	}
+	return -1
+}
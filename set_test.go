@@ -0,0 +1,134 @@
+package dr
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetMatch(t *testing.T) {
+	patterns := []string{
+		"abc*+aad",
+		"(a+b)*abb",
+		"!(a+b*(asd(!d)))+(def)*",
+		"(a+ab)*(b+ba)*",
+		".*a..",
+		"a*",
+	}
+	inputs := []string{
+		"",
+		"a",
+		"aad",
+		"abccc",
+		"abb",
+		"ababb",
+		"asdd",
+		"defdef",
+		"abab",
+		"abba",
+		"xxaxy",
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	}
+
+	rs := make([]Regex, len(patterns))
+	for i, p := range patterns {
+		rs[i] = MustParse(p)
+	}
+	set := NewSet(rs)
+
+	for _, s := range inputs {
+		var want []int
+		for i, r := range rs {
+			if Match(r, s) {
+				want = append(want, i)
+			}
+		}
+
+		if got := set.Match(s); !reflect.DeepEqual(got, want) {
+			t.Errorf("Match(%q) = %v, want %v", s, got, want)
+		}
+
+		first := -1
+		if len(want) != 0 {
+			first = want[0]
+		}
+		if got := set.First(s); got != first {
+			t.Errorf("First(%q) = %v, want %v", s, got, first)
+		}
+	}
+}
+
+func TestSetManyPatterns(t *testing.T) {
+	var rs []Regex
+	for c := 'a'; c < 'a'+100; c++ {
+		rs = append(rs, NewConcat(NewChar(c), NewKleene(NewAny())))
+	}
+	set := NewSet(rs)
+
+	if got := set.Match(string('a'+70) + "xyz"); !reflect.DeepEqual(got, []int{70}) {
+		t.Errorf("Match = %v, want [70]", got)
+	}
+	if got := set.First("?"); got != -1 {
+		t.Errorf("First = %v, want -1", got)
+	}
+}
+
+func TestSetStateBound(t *testing.T) {
+	var rs []Regex
+	for _, p := range genPatterns {
+		rs = append(rs, MustParse(p))
+	}
+	set := NewSet(rs)
+	set.max = len(set.states) + 5
+
+	inputs := sampleInputs(strings.Join(genPatterns, ""), 200, rand.New(rand.NewSource(1)))
+	for _, s := range append(inputs, inputs...) {
+		var want []int
+		for j, r := range rs {
+			if Match(r, s) {
+				want = append(want, j)
+			}
+		}
+		if got := set.Match(s); !reflect.DeepEqual(got, want) {
+			t.Errorf("Match(%q) = %v, want %v", s, got, want)
+		}
+	}
+
+	if len(set.states) > set.max {
+		t.Errorf("Set cached %v states, want at most %v", len(set.states), set.max)
+	}
+}
+
+func TestSetConcurrent(t *testing.T) {
+	var rs []Regex
+	for _, p := range genPatterns {
+		rs = append(rs, MustParse(p))
+	}
+	set := NewSet(rs)
+
+	inputs := sampleInputs(strings.Join(genPatterns, ""), 500, rand.New(rand.NewSource(1)))
+	want := make([][]int, len(inputs))
+	for i, s := range inputs {
+		for j, r := range rs {
+			if Match(r, s) {
+				want[i] = append(want[i], j)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, s := range inputs {
+				if got := set.Match(s); !reflect.DeepEqual(got, want[i]) {
+					t.Errorf("Match(%q) = %v, want %v", s, got, want[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,205 @@
+package dr
+
+import (
+	"bytes"
+	"sort"
+)
+
+// termKey returns a string that uniquely identifies the structure of a
+// regex. Unlike String, it is unambiguous, so two regexes have the same
+// key if and only if they are the same term. Regexes not defined in this
+// package fall back to their String.
+func termKey(r Regex) string {
+	var buf bytes.Buffer
+	writeKey(&buf, r)
+	return buf.String()
+}
+
+func writeKey(buf *bytes.Buffer, r Regex) {
+	switch r := r.(type) {
+	case *empty:
+		buf.WriteString("0")
+	case *epsilon:
+		buf.WriteString("1")
+	case *any:
+		buf.WriteString(".")
+	case *char:
+		buf.WriteString("c")
+		buf.WriteRune(r.r)
+	case *class:
+		buf.WriteString("[(")
+		buf.WriteString(r.name)
+		buf.WriteString(")")
+	case *union:
+		buf.WriteString("+(")
+		writeKey(buf, r.l)
+		buf.WriteString(",")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *intersection:
+		buf.WriteString("&(")
+		writeKey(buf, r.l)
+		buf.WriteString(",")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *concat:
+		buf.WriteString(";(")
+		writeKey(buf, r.l)
+		buf.WriteString(",")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *comp:
+		buf.WriteString("!(")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *kleene:
+		buf.WriteString("*(")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *anchor:
+		buf.WriteString("a")
+		buf.WriteByte(r.kind)
+	case *lookahead:
+		if r.neg {
+			buf.WriteString("?!(")
+		} else {
+			buf.WriteString("?=(")
+		}
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	case *located:
+		buf.WriteString("@")
+		buf.WriteByte('0' + byte(r.prev))
+		buf.WriteString("(")
+		writeKey(buf, r.r)
+		buf.WriteString(")")
+	default:
+		buf.WriteString("?(")
+		buf.WriteString(r.String())
+		buf.WriteString(")")
+	}
+}
+
+// termSet is an ordered set of regexes, deduplicated by structure.
+// Empty regexes are never added.
+type termSet struct {
+	terms []Regex
+	keys  []string
+	seen  map[string]bool
+}
+
+func (s *termSet) add(r Regex) {
+	if isEmpty(r) {
+		return
+	}
+
+	k := termKey(r)
+	if s.seen[k] {
+		return
+	}
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	s.seen[k] = true
+	s.terms = append(s.terms, r)
+	s.keys = append(s.keys, k)
+}
+
+func (s *termSet) Len() int           { return len(s.terms) }
+func (s *termSet) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s *termSet) Swap(i, j int) {
+	s.terms[i], s.terms[j] = s.terms[j], s.terms[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// simplify rewrites r using the similarity rules from Brzozowski's paper,
+// so that repeatedly taking derivatives only produces finitely many
+// distinct terms. Unions are flattened, deduplicated and sorted,
+// concatenations are reassociated to the right, and redundant
+// complements and stars are removed. Lookaheads in sequence are sorted
+// and deduplicated, so they don't pile up as derivatives carry them.
+// Memoized nodes are already simplified, and are returned as they are to
+// keep their caches.
+func simplify(r Regex) Regex {
+	if memoOf(r) != nil {
+		return r
+	}
+
+	switch r := r.(type) {
+	case *union:
+		var s termSet
+		addAlternatives(&s, r)
+		sort.Sort(&s)
+
+		if len(s.terms) == 0 {
+			return NewEmpty()
+		}
+		u := s.terms[len(s.terms)-1]
+		for i := len(s.terms) - 2; i >= 0; i-- {
+			u = NewUnion(s.terms[i], u)
+		}
+		return u
+	case *intersection:
+		l, rr := simplify(r.l), simplify(r.r)
+		if termKey(l) == termKey(rr) {
+			return l
+		}
+		return NewIntersection(l, rr)
+	case *concat:
+		l, rr := simplify(r.l), simplify(r.r)
+		if isEmpty(l) || isEmpty(rr) {
+			return NewEmpty()
+		}
+		if _, ok := rr.(*epsilon); ok {
+			return l
+		}
+		if isCondition(l) {
+			return mergeConditions(l, rr)
+		}
+		return concatRight(l, rr)
+	case *comp:
+		inner := simplify(r.r)
+		if c, ok := inner.(*comp); ok {
+			return c.r
+		}
+		return NewComp(inner)
+	case *kleene:
+		inner := simplify(r.r)
+		switch inner.(type) {
+		case *kleene:
+			return inner
+		case *empty, *epsilon:
+			return NewEpsilon()
+		}
+		return NewKleene(inner)
+	case *lookahead:
+		return NewLookahead(simplify(r.r), r.neg)
+	default:
+		return r
+	}
+}
+
+func addAlternatives(s *termSet, r Regex) {
+	if u, ok := r.(*union); ok {
+		addAlternatives(s, u.l)
+		addAlternatives(s, u.r)
+		return
+	}
+
+	r = simplify(r)
+	if _, ok := r.(*union); ok {
+		addAlternatives(s, r)
+		return
+	}
+	s.add(r)
+}
+
+func isEmpty(r Regex) bool {
+	_, ok := r.(*empty)
+	return ok
+}
+
+func isEpsilon(r Regex) bool {
+	_, ok := r.(*epsilon)
+	return ok
+}
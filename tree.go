@@ -1,7 +1,27 @@
 package dr
 
+import "fmt"
+
 type regexTree struct {
 	stack []Regex
+	err   *treeError
+	bytes bool
+
+	// lookup returns the regex a {name} reference stands for.
+	lookup func(name string) (Regex, *classError)
+}
+
+// treeError is a problem found while building the tree, at a byte offset
+// within the text starting at rune pos of the pattern.
+type treeError struct {
+	pos int
+	*classError
+}
+
+func (t *regexTree) fail(pos int, err *classError) {
+	if t.err == nil {
+		t.err = &treeError{pos: pos, classError: err}
+	}
 }
 
 func (t *regexTree) get() Regex {
@@ -25,10 +45,59 @@ func (t *regexTree) empty() bool {
 	return len(t.stack) == 0
 }
 
-func (t *regexTree) char(r rune) {
+func (t *regexTree) char(r rune, begin int) {
+	if t.bytes && r > 0xFF {
+		t.fail(begin, &classError{0, fmt.Sprintf("'%v' is not a byte", escapeRune(r))})
+	}
 	t.push(NewChar(r))
 }
 
+func (t *regexTree) escape(text string, begin int) {
+	r, _, err := unescape(text)
+	if err != nil {
+		t.fail(begin, err)
+		t.push(NewEmpty())
+		return
+	}
+	t.char(r, begin)
+}
+
+func (t *regexTree) ref(text string, begin int) {
+	r, err := t.lookup(text[1 : len(text)-1])
+	if err != nil {
+		t.fail(begin, err)
+		t.push(NewEmpty())
+		return
+	}
+	t.push(r)
+}
+
+func (t *regexTree) class(text string, begin int) {
+	c, err := parseClass(text)
+	if err != nil {
+		t.fail(begin, err)
+		t.push(NewEmpty())
+		return
+	}
+	t.push(c)
+}
+
+func (t *regexTree) anchor(text string) {
+	switch text {
+	case "^":
+		t.push(NewStart())
+	case "$":
+		t.push(NewEnd())
+	default:
+		t.push(NewWordBoundary(text == `\B`))
+	}
+}
+
+func (t *regexTree) lookahead(neg bool) {
+	r := t.pop()
+	t.push(NewLookahead(r, neg))
+}
+
 func (t *regexTree) any() {
 	t.push(NewAny())
 }
@@ -50,6 +119,13 @@ func (t *regexTree) concat() {
 	t.push(NewConcat(b, a))
 }
 
+func (t *regexTree) intersection() {
+	a := t.pop()
+	b := t.pop()
+
+	t.push(NewIntersection(b, a))
+}
+
 func (t *regexTree) union() {
 	a := t.pop()
 	b := t.pop()
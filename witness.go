@@ -0,0 +1,50 @@
+package dr
+
+// Witness returns one of the shortest strings matched by r. If r matches
+// nothing, it returns false.
+//
+// The search is breadth first over the derivatives of r, which are
+// finite in number once simplified. Where any of several characters
+// would do, letters and digits are preferred, so witnesses are readable.
+func Witness(r Regex) (string, bool) {
+	r = simplify(locate(r))
+	alpha := newAlphabet(r)
+
+	type node struct {
+		r    Regex
+		prev int
+		c    rune
+	}
+	nodes := []node{{r: r, prev: -1}}
+	seen := map[string]bool{termKey(r): true}
+
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].r.Accepting() {
+			var rs []rune
+			for j := i; nodes[j].prev >= 0; j = nodes[j].prev {
+				rs = append(rs, nodes[j].c)
+			}
+			for l, r := 0, len(rs)-1; l < r; l, r = l+1, r-1 {
+				rs[l], rs[r] = rs[r], rs[l]
+			}
+			return string(rs), true
+		}
+
+		for class := 0; class < alpha.size(); class++ {
+			c := alpha.pick(class)
+			next := simplify(nodes[i].r.Derivative(c))
+			if isEmpty(next) {
+				continue
+			}
+
+			k := termKey(next)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			nodes = append(nodes, node{r: next, prev: i, c: c})
+		}
+	}
+
+	return "", false
+}
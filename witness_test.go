@@ -0,0 +1,48 @@
+package dr
+
+import "testing"
+
+func TestWitness(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		ok      bool
+	}{
+		{"abc", "abc", true},
+		{"a*", "", true},
+		{"(ab)*c+d", "c", true},
+		{"!(.*)", "", false},
+		{"!a", "", true},
+		{"!(a*)", "0", true},
+		{".", "a", true},
+		{"[^a-z0-9A-Z]", "!", true},
+		{`\d\d`, "00", true},
+		{"a^", "", false},
+		{`\bx$`, "x", true},
+		{"(?=ab)a.", "ab", true},
+		{"(?=a)b", "", false},
+	}
+
+	for _, test := range tests {
+		r := MustParse(test.pattern)
+		got, ok := Witness(r)
+		if got != test.want || ok != test.ok {
+			t.Errorf("Witness(%q) = %q, %v, want %q, %v", test.pattern, got, ok, test.want, test.ok)
+		}
+		if ok && !Match(r, got) {
+			t.Errorf("Witness(%q) = %q, which doesn't match", test.pattern, got)
+		}
+	}
+}
+
+func TestDerive(t *testing.T) {
+	for _, p := range []string{"ab*c", `.*\bab`, "!(a.*)"} {
+		r := MustParse(p)
+		for _, s := range []string{"", "a", "ab", "abc", "x ab"} {
+			d := Derive(Derive(r, s[:len(s)/2]), s[len(s)/2:])
+			if got, want := d.Accepting(), Match(r, s); got != want {
+				t.Errorf("Derive(%q, %q).Accepting() = %v, want %v", p, s, got, want)
+			}
+		}
+	}
+}